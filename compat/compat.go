@@ -0,0 +1,181 @@
+package compat
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// ssh option with value
+var sshValueFlags = map[string]bool{
+	"-b": true, "-c": true, "-D": true, "-E": true, "-e": true, "-F": true,
+	"-I": true, "-i": true, "-J": true, "-L": true, "-l": true, "-m": true,
+	"-O": true, "-o": true, "-p": true, "-Q": true, "-R": true, "-S": true,
+	"-W": true, "-w": true,
+}
+
+// ssh option without value
+var sshBoolFlags = map[string]bool{
+	"-4": true, "-6": true, "-A": true, "-a": true, "-C": true, "-f": true,
+	"-G": true, "-g": true, "-K": true, "-k": true, "-M": true, "-N": true,
+	"-n": true, "-q": true, "-s": true, "-T": true, "-t": true, "-V": true,
+	"-v": true, "-X": true, "-x": true, "-Y": true, "-y": true,
+}
+
+var destinationRegexp = regexp.MustCompile(`^[A-Za-z0-9._+-]+@[^\s/@]+$`)
+
+// ssh compatible command line
+type Args struct {
+	User    string
+	Host    string
+	Port    string
+	Key     string
+	Options []string // -o Key=Value
+	SshArgs []string // passthrough to ssh (-L/-R/-D ...)
+
+	// -J jump hosts
+	ProxyJump string
+
+	Command []string
+
+	// -s (command is subsystem name)
+	Subsystem bool
+}
+
+// Check ssh destination ("ssh://user@host:port" or "user@host")
+func isDestination(str string) bool {
+	return strings.HasPrefix(str, "ssh://") || destinationRegexp.MatchString(str)
+}
+
+// Check command line is ssh compatible (lssh [ssh options] user@host [command])
+func IsCompatArgs(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case sshValueFlags[arg]:
+			i++
+		case sshValueFlags[flagName(arg)], sshBoolFlags[arg]:
+		case strings.HasPrefix(arg, "-"):
+			return false
+		default:
+			return isDestination(arg)
+		}
+	}
+	return false
+}
+
+// "-p2222" => "-p"
+func flagName(arg string) string {
+	if len(arg) > 2 && strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") {
+		return arg[:2]
+	}
+	return arg
+}
+
+// Parse ssh compatible command line
+func ParseArgs(args []string) (result Args, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// destination and remote command
+		if !strings.HasPrefix(arg, "-") {
+			if err = parseDestination(arg, &result); err != nil {
+				return
+			}
+			result.Command = args[i+1:]
+			break
+		}
+
+		if sshBoolFlags[arg] {
+			if arg == "-s" {
+				result.Subsystem = true
+			}
+			result.SshArgs = append(result.SshArgs, arg)
+			continue
+		}
+
+		// get option value ("-p 2222" or "-p2222")
+		name := flagName(arg)
+		if !sshValueFlags[name] {
+			err = fmt.Errorf("option %s is not supported", arg)
+			return
+		}
+		value := arg[len(name):]
+		if value == "" {
+			if i+1 >= len(args) {
+				err = fmt.Errorf("option %s need value", arg)
+				return
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "-i":
+			result.Key = value
+		case "-p":
+			result.Port = value
+		case "-l":
+			result.User = value
+		case "-o":
+			result.Options = append(result.Options, value)
+		case "-J":
+			result.ProxyJump = value
+		default:
+			result.SshArgs = append(result.SshArgs, name, value)
+		}
+	}
+
+	if result.Host == "" {
+		err = fmt.Errorf("destination is not specified")
+	}
+	return
+}
+
+// Parse "ssh://user@host:port" or "user@host"
+func parseDestination(str string, result *Args) error {
+	if strings.HasPrefix(str, "ssh://") {
+		u, err := url.Parse(str)
+		if err != nil {
+			return err
+		}
+		if u.User != nil && u.User.Username() != "" {
+			result.User = u.User.Username()
+		}
+		result.Host = u.Hostname()
+		if u.Port() != "" {
+			result.Port = u.Port()
+		}
+		return nil
+	}
+
+	i := strings.LastIndex(str, "@")
+	if i >= 0 {
+		result.User = str[:i]
+		str = str[i+1:]
+	}
+	host, port, err := conf.SplitHostPort(str)
+	if err != nil {
+		return err
+	}
+	result.Host = host
+	if port != "" && result.Port == "" {
+		result.Port = port
+	}
+	return nil
+}
+
+// Get server name ("user@host[:port]")
+func (a Args) ServerName() string {
+	name := a.User + "@" + a.Host
+	if strings.Contains(a.Host, ":") {
+		name = a.User + "@[" + a.Host + "]"
+	}
+	if a.Port != "" && a.Port != conf.DefaultPort {
+		name = name + ":" + a.Port
+	}
+	return name
+}
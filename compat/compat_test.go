@@ -0,0 +1,149 @@
+package compat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    Args
+		wantErr bool
+	}{
+		{
+			args: "user@host",
+			want: Args{User: "user", Host: "host"},
+		},
+		{
+			args: "user@host ls -la",
+			want: Args{User: "user", Host: "host", Command: []string{"ls", "-la"}},
+		},
+		{
+			args: "user@host -p 2222",
+			want: Args{User: "user", Host: "host", Command: []string{"-p", "2222"}},
+		},
+		{
+			args: "-p 2222 user@host",
+			want: Args{User: "user", Host: "host", Port: "2222"},
+		},
+		{
+			args: "-p2222 user@host",
+			want: Args{User: "user", Host: "host", Port: "2222"},
+		},
+		{
+			args: "-p 2222 user@host:22",
+			want: Args{User: "user", Host: "host", Port: "2222"},
+		},
+		{
+			args: "user@host:2022",
+			want: Args{User: "user", Host: "host", Port: "2022"},
+		},
+		{
+			args: "user@[fe80::1%eth0]:2022",
+			want: Args{User: "user", Host: "fe80::1%eth0", Port: "2022"},
+		},
+		{
+			args: "ssh://user@host:2222",
+			want: Args{User: "user", Host: "host", Port: "2222"},
+		},
+		{
+			args: "ssh://host uptime",
+			want: Args{Host: "host", Command: []string{"uptime"}},
+		},
+		{
+			args: "-J bastion1,bastion2 user@host",
+			want: Args{User: "user", Host: "host", ProxyJump: "bastion1,bastion2"},
+		},
+		{
+			args: "-Jbastion user@host",
+			want: Args{User: "user", Host: "host", ProxyJump: "bastion"},
+		},
+		{
+			args: "-i ~/.ssh/id_ed25519 -l other user@host",
+			want: Args{User: "user", Host: "host", Key: "~/.ssh/id_ed25519"},
+		},
+		{
+			args: "-o StrictHostKeyChecking=no -oServerAliveInterval=30 user@host",
+			want: Args{User: "user", Host: "host", Options: []string{"StrictHostKeyChecking=no", "ServerAliveInterval=30"}},
+		},
+		{
+			args: "-L 8080:localhost:80 -D1080 -t user@host",
+			want: Args{User: "user", Host: "host", SshArgs: []string{"-L", "8080:localhost:80", "-D", "1080", "-t"}},
+		},
+		{
+			args: "-s user@host sftp",
+			want: Args{User: "user", Host: "host", SshArgs: []string{"-s"}, Subsystem: true, Command: []string{"sftp"}},
+		},
+		{args: "-p", wantErr: true},
+		{args: "--unknown user@host", wantErr: true},
+		{args: "-t", wantErr: true},
+		{args: "user@host:99999", wantErr: true},
+		{args: "ssh://user@host:bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			got, err := ParseArgs(strings.Fields(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Command) == 0 {
+				got.Command = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCompatArgs(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"user@host", true},
+		{"user@host uptime", true},
+		{"ssh://host", true},
+		{"-p 2222 user@host", true},
+		{"-p2222 -t user@host", true},
+		{"-J bastion user@host", true},
+		{"", false},
+		{"-H web1", false},
+		{"--notify user@host", false},
+		{"uptime", false},
+		{"-p 2222", false},
+		{"user@host/path", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			if got := IsCompatArgs(strings.Fields(tt.args)); got != tt.want {
+				t.Errorf("IsCompatArgs(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerName(t *testing.T) {
+	tests := []struct {
+		args Args
+		want string
+	}{
+		{Args{User: "user", Host: "host"}, "user@host"},
+		{Args{User: "user", Host: "host", Port: "22"}, "user@host"},
+		{Args{User: "user", Host: "host", Port: "2222"}, "user@host:2222"},
+		{Args{User: "user", Host: "fe80::1", Port: "2222"}, "user@[fe80::1]:2222"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.args.ServerName(); got != tt.want {
+			t.Errorf("%+v.ServerName() = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package compat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Split ssh option ("Key=Value" or "Key Value")
+func splitOption(option string) (key, value string, err error) {
+	option = strings.TrimSpace(option)
+	i := strings.IndexAny(option, "= \t")
+	if i < 0 {
+		err = fmt.Errorf("option %s need value", option)
+		return
+	}
+	key = strings.ToLower(option[:i])
+	value = strings.Trim(strings.TrimSpace(option[i+1:]), "=\" \t")
+	return
+}
+
+// Translate ssh -o options to server config.
+// Unknown options are returned (passthrough to ssh).
+func ApplyOptions(serverConf conf.ReadConfig, options []string) (conf.ReadConfig, []string, error) {
+	unknown := []string{}
+	for _, option := range options {
+		key, value, err := splitOption(option)
+		if err != nil {
+			return serverConf, unknown, err
+		}
+
+		switch key {
+		case "user":
+			serverConf.User = value
+		case "port":
+			if err := conf.CheckPort(value); err != nil {
+				return serverConf, unknown, err
+			}
+			serverConf.Port = value
+		case "hostname":
+			serverConf.Addr = value
+		case "identityfile":
+			serverConf.Key = value
+		case "proxyjump":
+			if value != "none" {
+				serverConf.ProxyJump = value
+			}
+		case "stricthostkeychecking":
+			serverConf.StrictHostKeyChecking = value
+		case "userknownhostsfile":
+			serverConf.KnownHostsFile = value
+		case "connecttimeout", "serveraliveinterval":
+			sec, err := strconv.Atoi(value)
+			if err != nil || sec < 0 {
+				return serverConf, unknown, fmt.Errorf("option %s is not valid number", option)
+			}
+			if key == "connecttimeout" {
+				serverConf.ConnectTimeout = sec
+			} else {
+				serverConf.KeepaliveInterval = sec
+			}
+		case "loglevel":
+			if strings.ToLower(value) == "quiet" {
+				serverConf.Quiet = true
+			} else {
+				unknown = append(unknown, option)
+			}
+		default:
+			unknown = append(unknown, option)
+		}
+	}
+	return serverConf, unknown, nil
+}
@@ -0,0 +1,38 @@
+package conf
+
+import (
+	"sort"
+	"strings"
+)
+
+// Get server name from alias (role name, CNAME, IP ...)
+func GetAliasServer(listConf Config, alias string) (server string, ok bool) {
+	for name, serverConf := range listConf.Server {
+		for _, v := range serverConf.Aliases {
+			if strings.EqualFold(v, alias) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Check alias is not same as other server name or alias
+func checkAlias(checkConf Config) (errs []ConfigError) {
+	names := GetNameList(checkConf)
+	sort.Strings(names)
+
+	aliases := map[string]string{}
+	for _, name := range names {
+		for _, v := range checkConf.Server[name].Aliases {
+			key := strings.ToLower(v)
+			if _, ok := checkConf.Server[v]; ok {
+				errs = append(errs, newConfigError(name, "%s: alias %s is same name server.", name, v))
+			} else if other, ok := aliases[key]; ok && other != name {
+				errs = append(errs, newConfigError(name, "%s: alias %s is already used by %s.", name, v, other))
+			}
+			aliases[key] = name
+		}
+	}
+	return
+}
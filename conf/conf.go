@@ -2,14 +2,46 @@ package conf
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/BurntSushi/toml"
+	"github.com/blacknon/lssh/i18n"
 )
 
+const DefaultPort = "22"
+
 type Config struct {
+	// default identity files
+	Identities []string `toml:"identities"`
+
 	Log    LogConfig
+	Title  TitleConfig
+	UI     UIConfig     `toml:"ui"`
+	Dirs   DirsConfig   `toml:"dirs"`
+	Prompt PromptConfig `toml:"prompt"`
 	Server map[string]ReadConfig
+	Match  []MatchConfig `toml:"match"`
+
+	Group map[string]ReadConfig `toml:"group"`
+
+	Template map[string]TemplateConfig `toml:"template"`
+
+	Include IncludeConfig `toml:"include"`
+
+	// server definition files (toml, yaml or json)
+	Includes []string `toml:"includes"`
+
+	RootWarn RootWarnConfig `toml:"root_warn"`
+
+	Secrets SecretsConfig `toml:"secrets"`
+
+	Inventory InventoryConfig `toml:"inventory"`
+
+	Sync map[string]SyncConfig `toml:"sync"`
 }
 
 type ReadConfig struct {
@@ -19,6 +51,107 @@ type ReadConfig struct {
 	Pass string `toml:"pass"`
 	Key  string `toml:"key"`
 	Note string `toml:"note"`
+
+	// server tags (select with "lssh --tag prod", or "tag:prod" in list filter)
+	Tags []string `toml:"tags"`
+
+	// other names of server (role name, CNAME, IP), used in list filter and -H
+	Aliases []string `toml:"aliases"`
+
+	// inherit config from [group.<name>]
+	Group string `toml:"group"`
+
+	// key passphrase (pass and passphrase can be secret reference "vault:secret/path#field")
+	Passphrase string `toml:"passphrase"`
+
+	// command that outputs password / key passphrase (ex. "pass show server/%n")
+	PasswordCmd   string `toml:"password_cmd"`
+	PassphraseCmd string `toml:"passphrase_cmd"`
+
+	// get key passphrase from OS keychain (and store asked passphrase)
+	Keychain bool `toml:"keychain"`
+
+	// key expire date (YYYY-MM-DD, warning only)
+	KeyExpire string `toml:"key_expire"`
+
+	// identity files (try in order)
+	Identities []string `toml:"identities"`
+
+	// command that outputs ssh certificate (exec when cached certificate is expired)
+	CertCommand string `toml:"cert_command"`
+
+	// remote server character encoding (ex. "shift_jis", "euc-kr")
+	Encoding string `toml:"encoding"`
+
+	// Wake-on-LAN
+	Mac       string `toml:"mac"`
+	Broadcast string `toml:"broadcast"`
+	Wol       bool   `toml:"wol"`
+	WolVia    string `toml:"wol_via"`
+	WolWait   int    `toml:"wol_wait"`
+
+	// Remote TERM value, and send local terminfo to remote
+	Term     string `toml:"term"`
+	Terminfo bool   `toml:"terminfo"`
+
+	// Login shell and locale
+	Shell       string `toml:"shell"`
+	ForceLocale string `toml:"force_locale"`
+
+	// rc files (sourced at remote shell start, remove at exit)
+	RcFiles []string `toml:"rcfiles"`
+
+	// output command boundary markers (OSC 133) at remote bash, for session log
+	ShellIntegration bool `toml:"shell_integration"`
+
+	// keepalive request interval(sec)
+	KeepaliveInterval int `toml:"keepalive_interval"`
+
+	// connect timeout(sec)
+	ConnectTimeout int `toml:"connect_timeout"`
+
+	// crypto policy ("modern", "fips", "legacy"), and algorithm lists (override policy)
+	Policy            string   `toml:"policy"`
+	Ciphers           []string `toml:"ciphers"`
+	Macs              []string `toml:"macs"`
+	KexAlgorithms     []string `toml:"kex_algorithms"`
+	HostKeyAlgorithms []string `toml:"host_key_algorithms"`
+
+	// rekey after data size and/or time (same as OpenSSH RekeyLimit, ex. "1G 1h")
+	RekeyLimit string `toml:"rekey_limit"`
+
+	// jump hosts ("user@host:port,..." or server name)
+	ProxyJump string `toml:"proxy_jump"`
+
+	// host key check ("yes", "no", "accept-new"), and known_hosts file path
+	StrictHostKeyChecking string `toml:"strict_host_key_checking"`
+	KnownHostsFile        string `toml:"known_hosts_file"`
+
+	// warn at connect when remote clock skew exceeds this value(sec). 0 is not check.
+	ClockSkewWarn int `toml:"clock_skew_warn"`
+
+	// save remote environment snapshot (os, uptime) at connect, and show it at server list
+	Snapshot bool `toml:"snapshot"`
+
+	// ssh command args (terminal connect only)
+	SshArgs []string `toml:"ssh_args"`
+
+	// env sent to remote server (like OpenSSH SetEnv, need AcceptEnv at remote sshd)
+	Env map[string]string `toml:"env"`
+
+	// terminal background color while connected (color name "red", or "#rrggbb")
+	Color string `toml:"color"`
+
+	// remove from server list after ttl from first seen (ex. "72h"), and purge cached host key and secret
+	Ttl string `toml:"ttl"`
+
+	// Suppress ssh banner and connect messages
+	Quiet bool `toml:"quiet"`
+
+	// TCP tuning (use command exec only)
+	TcpNoDelay *bool `toml:"tcp_nodelay"`
+	TcpSndBuf  int   `toml:"tcp_sndbuf"`
+	TcpRcvBuf  int   `toml:"tcp_rcvbuf"`
 }
 
 type LogConfig struct {
@@ -26,44 +159,300 @@ type LogConfig struct {
 	Dir    string `toml:"dirpath"`
 }
 
+type TitleConfig struct {
+	Enable bool   `toml:"enable"`
+	Format string `toml:"format"`
+}
+
+// Directory override (default is XDG base directory)
+type DirsConfig struct {
+	State string `toml:"state"`
+	Cache string `toml:"cache"`
+}
+
+type UIConfig struct {
+	Lang  string `toml:"lang"`
+	Plain bool   `toml:"plain"`
+	Mouse bool   `toml:"mouse"`
+
+	// Regexp to split server name into hierarchy (submatches are groups, ex. `^(\w+)-(\w+)-`)
+	Hierarchy string `toml:"hierarchy"`
+
+	// External picker command for server list (ex. "fzf")
+	Picker string `toml:"picker"`
+
+	// Ask to add ad-hoc server (lssh user@host) to config
+	AddPrompt bool `toml:"add_prompt"`
+
+	// Pinned servers for number key 1-9, 0 (and "lssh @3")
+	Pins []string `toml:"pins"`
+}
+
+// Warning at root shell connect (uid probe at session start)
+type RootWarnConfig struct {
+	Enable bool `toml:"enable"`
+
+	// terminal background color at root shell (color name "red", or "#rrggbb")
+	Color string `toml:"color"`
+
+	// terminal title prefix (default "[ROOT] ")
+	Title string `toml:"title"`
+}
+
+// Prompt backend per prompt type ("auto", "tty", "askpass", "pinentry")
+type PromptConfig struct {
+	Password   string `toml:"password"`
+	Passphrase string `toml:"passphrase"`
+	Otp        string `toml:"otp"`
+}
+
 func ConfigCheckRead(confPath string) (checkConf Config) {
-	var checkAlertFlag int = 0
+	checkConf, errs, err := readConfig(confPath)
+	if err != nil {
+		panic(err)
+	}
 
+	for _, e := range errs {
+		fmt.Println(e.Message)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+
+	return
+}
+
+// Read config, and check config values.
+// err is config file decode error, errs is config value errors.
+func readConfig(confPath string) (checkConf Config, errs []ConfigError, err error) {
 	// Read Config
-	_, err := toml.DecodeFile(confPath, &checkConf)
+	err = decodeConfigFile(confPath, &checkConf)
 	if err != nil {
-		panic(err)
+		return
+	}
+
+	// Set UI language
+	i18n.SetLang(checkConf.UI.Lang)
+
+	// Set directory override, and default log directory
+	dirOverride = checkConf.Dirs
+	if checkConf.Log.Enable && checkConf.Log.Dir == "" {
+		checkConf.Log.Dir = getDefaultLogDir()
+	}
+
+	// Read include files
+	if err := mergeIncludes(confPath, &checkConf); err != nil {
+		errs = append(errs, newConfigError("", "includes: %s", err))
+	}
+
+	// Team shared config servers
+	if err := mergeSync(&checkConf); err != nil {
+		errs = append(errs, newConfigError("", "sync: %s", err))
+	}
+
+	// Dynamic inventory servers
+	for _, err := range mergeInventory(&checkConf) {
+		fmt.Fprintf(os.Stderr, "inventory: %s\n", err)
+	}
+
+	// Import ssh_config hosts
+	if checkConf.Include.SshConfig != nil {
+		if checkConf.Include.SshConfig.Path == "" {
+			checkConf.Include.SshConfig.Path = "~/.ssh/config"
+		}
+		if err := mergeSshConfig(&checkConf); err != nil {
+			errs = append(errs, newConfigError("", "include.sshconfig: %s", err))
+		}
+	}
+
+	if checkConf.UI.Hierarchy != "" {
+		if _, err := regexp.Compile(checkConf.UI.Hierarchy); err != nil {
+			errs = append(errs, newConfigError("", "ui: 'hierarchy' %s", err))
+		}
+	}
+
+	for i := range checkConf.Match {
+		checkConf.Match[i].Set = expandEnv(checkConf.Match[i].Set)
 	}
 
 	// Config Value Check
 	for k, v := range checkConf.Server {
+		// Apply group defaults (port in "host:port" addr is prior to group port)
+		if _, port, err := SplitHostPort(os.ExpandEnv(v.Addr)); err == nil && port != "" && v.Port == "" {
+			v.Port = port
+		}
+		if group, err := applyGroup(v, checkConf.Group); err != nil {
+			errs = append(errs, newConfigError(k, "%s: %s", k, err))
+		} else {
+			v = group
+		}
+
+		// Expand environment variables (${VAR}, $VAR)
+		v = expandEnv(v)
+
+		// Split "host:port" shorthand addr ("192.168.100.101:2222")
+		if addr, port, err := SplitHostPort(v.Addr); err != nil {
+			errs = append(errs, newConfigError(k, "%s: 'addr' %s", k, err))
+		} else if port != "" {
+			if v.Port != "" && v.Port != port {
+				errs = append(errs, newConfigError(k, "%s: 'addr' port and 'port' are different.", k))
+			}
+			v.Addr = addr
+			v.Port = port
+		}
+
+		// Remove IPv6 literal brackets ("[fe80::1%eth0]" => "fe80::1%eth0")
+		v.Addr = TrimAddrBrackets(v.Addr)
+
+		// Apply match blocks
+		v = applyMatch(k, v, checkConf.Match)
+
+		// Set default port
+		if v.Port == "" {
+			v.Port = DefaultPort
+		}
+		checkConf.Server[k] = v
+
+		if v.KeyExpire != "" {
+			if expire, err := time.Parse("2006-01-02", v.KeyExpire); err != nil {
+				errs = append(errs, newConfigError(k, "%s: 'key_expire' %s", k, err))
+			} else if time.Now().After(expire) {
+				fmt.Fprintf(os.Stderr, "%s: key is expired at %s.\n", k, v.KeyExpire)
+			}
+		}
+
+		if _, err := GetCryptoPolicy(v); err != nil {
+			errs = append(errs, newConfigError(k, "%s: %s", k, err))
+		}
+
+		if v.RekeyLimit != "" {
+			if _, _, err := ParseRekeyLimit(v.RekeyLimit); err != nil {
+				errs = append(errs, newConfigError(k, "%s: %s", k, err))
+			}
+		}
+
+		if err := CheckPort(v.Port); err != nil {
+			errs = append(errs, newConfigError(k, "%s: %s", k, err))
+		}
+
 		if v.Addr == "" {
-			fmt.Printf("%s: 'addr' is not inserted.\n", k)
-			checkAlertFlag = 1
+			errs = append(errs, newConfigError(k, i18n.T(i18n.ConfAddrNotSet), k))
 		}
 
 		if v.User == "" {
-			fmt.Printf("%s: 'user' is not inserted.\n", k)
-			checkAlertFlag = 1
+			errs = append(errs, newConfigError(k, i18n.T(i18n.ConfUserNotSet), k))
 		}
 
-		if v.Pass == "" && v.Key == "" {
-			fmt.Printf("%s: Both Password and KeyPath are entered.Please enter either.\n", k)
-			checkAlertFlag = 1
+		if v.Pass == "" && v.PasswordCmd == "" && v.Key == "" && len(v.Identities) == 0 && len(checkConf.Identities) == 0 {
+			errs = append(errs, newConfigError(k, i18n.T(i18n.ConfAuthNotSet), k))
 		}
 
 	}
 
-	if checkAlertFlag == 1 {
-		os.Exit(1)
+	// Remove expired ephemeral servers
+	if _, err := expireEphemeral(&checkConf); err != nil {
+		errs = append(errs, newConfigError("", "ttl: %s", err))
 	}
 
+	errs = append(errs, checkTemplate(checkConf)...)
+	errs = append(errs, checkAlias(checkConf)...)
+	errs = append(errs, checkPins(checkConf)...)
+
 	return
 }
 
+// Expand environment variables in server config values (addr, user, key path, proxy, note)
+func expandEnv(serverConf ReadConfig) ReadConfig {
+	serverConf.Addr = os.ExpandEnv(serverConf.Addr)
+	serverConf.User = os.ExpandEnv(serverConf.User)
+	serverConf.Key = os.ExpandEnv(serverConf.Key)
+	serverConf.ProxyJump = os.ExpandEnv(serverConf.ProxyJump)
+	serverConf.KnownHostsFile = os.ExpandEnv(serverConf.KnownHostsFile)
+	serverConf.Note = os.ExpandEnv(serverConf.Note)
+	if len(serverConf.Env) > 0 {
+		env := map[string]string{}
+		for name, value := range serverConf.Env {
+			env[name] = os.ExpandEnv(value)
+		}
+		serverConf.Env = env
+	}
+
+	identities := []string{}
+	for _, identity := range serverConf.Identities {
+		identities = append(identities, os.ExpandEnv(identity))
+	}
+	if len(identities) > 0 {
+		serverConf.Identities = identities
+	}
+	return serverConf
+}
+
 func GetNameList(listConf Config) (nameList []string) {
 	for k := range listConf.Server {
 		nameList = append(nameList, k)
 	}
 	return
 }
+
+// Get identity file list of server (key and identities, or global identities). "~" is not expanded.
+func GetIdentityList(serverConf ReadConfig, identities []string) (list []string) {
+	if serverConf.Key != "" {
+		list = append(list, serverConf.Key)
+	}
+	if len(serverConf.Identities) > 0 {
+		list = append(list, serverConf.Identities...)
+	} else if serverConf.Key == "" {
+		list = append(list, identities...)
+	}
+	return
+}
+
+// Check server has all tags
+func HasTags(serverConf ReadConfig, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, serverTag := range serverConf.Tags {
+			if strings.EqualFold(serverTag, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove brackets from IPv6 literal address
+func TrimAddrBrackets(addr string) string {
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		return addr[1 : len(addr)-1]
+	}
+	return addr
+}
+
+// Split "host:port" string. If port is not specified, return host only.
+// ex) "host:2222", "[fe80::1%eth0]:22", "fe80::1"
+func SplitHostPort(str string) (host, port string, err error) {
+	host, port, err = net.SplitHostPort(str)
+	if err != nil {
+		// not include port
+		if addrErr, ok := err.(*net.AddrError); ok && (addrErr.Err == "missing port in address" || addrErr.Err == "too many colons in address") {
+			return TrimAddrBrackets(str), "", nil
+		}
+		return
+	}
+
+	err = CheckPort(port)
+	return
+}
+
+// Check port number (1-65535)
+func CheckPort(port string) error {
+	num, err := strconv.Atoi(port)
+	if err != nil || num < 1 || num > 65535 {
+		return fmt.Errorf("'port' %s is not valid port number", port)
+	}
+	return nil
+}
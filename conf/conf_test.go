@@ -0,0 +1,66 @@
+package conf
+
+import "testing"
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		str     string
+		host    string
+		port    string
+		wantErr bool
+	}{
+		{"192.168.100.101", "192.168.100.101", "", false},
+		{"192.168.100.101:2222", "192.168.100.101", "2222", false},
+		{"web1.example.com", "web1.example.com", "", false},
+		{"web1.example.com:22", "web1.example.com", "22", false},
+		{"", "", "", false},
+		{"fe80::1", "fe80::1", "", false},
+		{"fe80::1%eth0", "fe80::1%eth0", "", false},
+		{"[fe80::1]", "fe80::1", "", false},
+		{"[fe80::1]:2222", "fe80::1", "2222", false},
+		{"[fe80::1%eth0]:22", "fe80::1%eth0", "22", false},
+		{"[fe80::1%eth0]", "fe80::1%eth0", "", false},
+		{"host:0", "", "", true},
+		{"host:65536", "", "", true},
+		{"host:ssh", "", "", true},
+		{"host:", "", "", true},
+		{"[fe80::1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			host, port, err := SplitHostPort(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitHostPort(%q) error = %v, wantErr %v", tt.str, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if host != tt.host || port != tt.port {
+				t.Errorf("SplitHostPort(%q) = %q, %q, want %q, %q", tt.str, host, port, tt.host, tt.port)
+			}
+		})
+	}
+}
+
+func TestCheckPort(t *testing.T) {
+	tests := []struct {
+		port    string
+		wantErr bool
+	}{
+		{"1", false},
+		{"22", false},
+		{"65535", false},
+		{"0", true},
+		{"65536", true},
+		{"-1", true},
+		{"", true},
+		{"ssh", true},
+	}
+
+	for _, tt := range tests {
+		if err := CheckPort(tt.port); (err != nil) != tt.wantErr {
+			t.Errorf("CheckPort(%q) error = %v, wantErr %v", tt.port, err, tt.wantErr)
+		}
+	}
+}
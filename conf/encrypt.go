@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageFileHeader  = "age-encryption.org/v1"
+	pgpArmorHeader = "-----BEGIN PGP MESSAGE-----"
+)
+
+// age decrypt config
+//
+//	[secrets.age]
+//	identity = "~/.config/lssh/age-key.txt"
+type AgeConfig struct {
+	// identity file (default is $LSSH_AGE_IDENTITY, or $XDG_CONFIG_HOME/lssh/age-key.txt)
+	Identity string `toml:"identity"`
+}
+
+// Check value is encrypted (armored age or gpg message)
+func IsEncrypted(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, ageArmorHeader) || strings.HasPrefix(value, pgpArmorHeader)
+}
+
+// Check encrypted config file by extension (".age", ".gpg", ".asc")
+func IsEncryptedFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".age", ".gpg", ".asc":
+		return true
+	}
+	return false
+}
+
+// Get age identity file path
+func getAgeIdentity(ageConf AgeConfig) string {
+	identity := ageConf.Identity
+	if identity == "" {
+		identity = os.Getenv("LSSH_AGE_IDENTITY")
+	}
+	if identity == "" {
+		identity = filepath.Join(getConfigBaseDir(), "age-key.txt")
+	}
+	return expandHome(identity)
+}
+
+// Decrypt age (with identity file) or gpg (with gpg-agent) encrypted data
+func decrypt(data []byte, ageConf AgeConfig) ([]byte, error) {
+	var cmd *exec.Cmd
+	text := string(bytes.TrimSpace(data))
+	if strings.HasPrefix(text, ageArmorHeader) || strings.HasPrefix(text, ageFileHeader) {
+		cmd = exec.Command("age", "--decrypt", "-i", getAgeIdentity(ageConf))
+	} else {
+		cmd = exec.Command("gpg", "--quiet", "--decrypt")
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s decrypt: %v %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Read and decrypt encrypted config file
+func readEncryptedFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = decrypt(data, AgeConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,151 @@
+package conf
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/blacknon/lssh/keychain"
+)
+
+// First seen time of ephemeral servers (<state dir>/ephemeral.json)
+func getEphemeralFile() (string, error) {
+	dir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ephemeral.json"), nil
+}
+
+// Remove expired ephemeral servers ("ttl" from first seen), and purge cached host keys and secrets.
+// Return removed server names.
+func expireEphemeral(checkConf *Config) (expired []string, err error) {
+	path, err := getEphemeralFile()
+	if err != nil {
+		return
+	}
+	firstSeen := map[string]time.Time{}
+	if data, readErr := ioutil.ReadFile(path); readErr == nil {
+		json.Unmarshal(data, &firstSeen)
+	}
+
+	changed := false
+	now := time.Now()
+	for name, serverConf := range checkConf.Server {
+		if serverConf.Ttl == "" {
+			continue
+		}
+		ttl, parseErr := time.ParseDuration(serverConf.Ttl)
+		if parseErr != nil {
+			return nil, fmt.Errorf("%s: 'ttl' %v", name, parseErr)
+		}
+
+		seen, ok := firstSeen[name]
+		if !ok {
+			firstSeen[name] = now
+			changed = true
+			continue
+		}
+		if now.Sub(seen) < ttl {
+			continue
+		}
+
+		// expired (first seen time is kept, so server is not shown again)
+		delete(checkConf.Server, name)
+		expired = append(expired, name)
+		if !seen.IsZero() {
+			purgeServerCache(name, serverConf, checkConf.Identities)
+			firstSeen[name] = time.Time{}
+			changed = true
+		}
+	}
+
+	if changed {
+		data, _ := json.Marshal(firstSeen)
+		err = ioutil.WriteFile(path, data, 0600)
+	}
+	return
+}
+
+// Purge cached host keys (known_hosts) and secrets (keychain) of server
+func purgeServerCache(name string, serverConf ReadConfig, identities []string) {
+	if serverConf.Keychain {
+		for _, identity := range GetIdentityList(serverConf, identities) {
+			keychain.Delete(keychain.KeyAccount(name, expandHome(identity)))
+		}
+	}
+
+	path := expandHome(serverConf.KnownHostsFile)
+	if path == "" {
+		path = GetProfileKnownHosts()
+	}
+	if path == "" {
+		usr, _ := user.Current()
+		path = filepath.Join(usr.HomeDir, ".ssh", "known_hosts")
+	}
+	port := serverConf.Port
+	if port == "" {
+		port = DefaultPort
+	}
+	removeKnownHosts(path, knownhosts.Normalize(net.JoinHostPort(serverConf.Addr, port)))
+}
+
+// Remove host lines from known_hosts file (include hashed hostname)
+func removeKnownHosts(path string, host string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+	removed := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) > 1 && !strings.HasPrefix(fields[0], "#") && !strings.HasPrefix(fields[0], "@") && matchKnownHost(fields[0], host) {
+			removed = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !removed {
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+// Check known_hosts hosts field ("host1,host2", or hashed "|1|salt|hash") matches host
+func matchKnownHost(hosts string, host string) bool {
+	if strings.HasPrefix(hosts, "|1|") {
+		parts := strings.Split(hosts, "|")
+		if len(parts) != 4 {
+			return false
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(host))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil)) == parts[3]
+	}
+
+	for _, h := range strings.Split(hosts, ",") {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
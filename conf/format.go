@@ -0,0 +1,159 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Check yaml config file by extension (".yaml", ".yml")
+func IsYamlFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Check json config file by extension (".json")
+func IsJsonFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
+// Decode config file (toml, yaml or json)
+// Encrypted file (".age", ".gpg", ".asc") is decrypted, and format is inner extension (ex. "lssh.conf.age").
+func decodeConfigFile(path string, v interface{}) error {
+	if IsEncryptedFile(path) {
+		data, err := readEncryptedFile(path)
+		if err != nil {
+			return err
+		}
+		return decodeConfigData(strings.TrimSuffix(path, filepath.Ext(path)), data, v)
+	}
+
+	if !IsYamlFile(path) && !IsJsonFile(path) {
+		_, err := toml.DecodeFile(path, v)
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return decodeConfigData(path, data, v)
+}
+
+// Decode config data (format is by path extension)
+func decodeConfigData(path string, data []byte, v interface{}) error {
+	if !IsYamlFile(path) && !IsJsonFile(path) {
+		_, err := toml.Decode(string(data), v)
+		return err
+	}
+
+	raw := map[string]interface{}{}
+	var err error
+	if IsJsonFile(path) {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return decodeMap(raw, v)
+}
+
+// Decode map with toml key name (convert to toml, and decode it)
+func decodeMap(raw map[string]interface{}, v interface{}) error {
+	buffer := &bytes.Buffer{}
+	if err := toml.NewEncoder(buffer).Encode(coerceValue(raw, reflect.TypeOf(v).Elem())); err != nil {
+		return err
+	}
+	_, err := toml.Decode(buffer.String(), v)
+	return err
+}
+
+// Convert yaml/json value to config field type (ex. port: 22 => "22")
+func coerceValue(value interface{}, typ reflect.Type) interface{} {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			if item == nil {
+				continue
+			}
+			switch typ.Kind() {
+			case reflect.Struct:
+				if field, ok := getTomlField(typ, key); ok {
+					result[key] = coerceValue(item, field.Type)
+					continue
+				}
+			case reflect.Map:
+				result[key] = coerceValue(item, typ.Elem())
+				continue
+			}
+			result[key] = item
+		}
+		return result
+	case []interface{}:
+		if typ.Kind() != reflect.Slice {
+			return v
+		}
+		result := []interface{}{}
+		for _, item := range v {
+			result = append(result, coerceValue(item, typ.Elem()))
+		}
+		return result
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return fmt.Sprint(value)
+		}
+	case reflect.Int, reflect.Int64:
+		// json number is float64
+		if f, ok := value.(float64); ok && f == float64(int64(f)) {
+			return int64(f)
+		}
+	}
+	return value
+}
+
+// Get struct field by toml key name (or field name)
+func getTomlField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == key || (name == "" && strings.EqualFold(field.Name, key)) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Get default config file path in dir (<name>.conf, or <name>.yaml / <name>.yml if exists)
+func getConfPathInDir(dir string, name string) string {
+	confPath := filepath.Join(dir, name+".conf")
+	if _, err := os.Stat(confPath); err == nil {
+		return confPath
+	}
+	for _, ext := range []string{".yaml", ".yml", ".conf.age", ".conf.gpg"} {
+		if _, err := os.Stat(filepath.Join(dir, name+ext)); err == nil {
+			return filepath.Join(dir, name+ext)
+		}
+	}
+	return confPath
+}
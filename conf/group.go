@@ -0,0 +1,30 @@
+package conf
+
+import "fmt"
+
+// Apply server group defaults (values not set in server config).
+// Group can also inherit other group with `group`.
+//
+//	[group.prod]
+//	user = "deploy"
+//	key  = "~/.ssh/deploy_key"
+//	[server.web1]
+//	addr  = "192.168.100.101"
+//	group = "prod"
+func applyGroup(serverConf ReadConfig, groups map[string]ReadConfig) (ReadConfig, error) {
+	visited := map[string]bool{}
+	for name := serverConf.Group; name != ""; {
+		if visited[name] {
+			return serverConf, fmt.Errorf("'group' %s is loop", name)
+		}
+		visited[name] = true
+
+		group, ok := groups[name]
+		if !ok {
+			return serverConf, fmt.Errorf("'group' %s not found", name)
+		}
+		serverConf = mergeConfig(serverConf, group)
+		name = group.Group
+	}
+	return serverConf, nil
+}
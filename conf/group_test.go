@@ -0,0 +1,108 @@
+package conf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestApplyGroup(t *testing.T) {
+	groups := map[string]ReadConfig{
+		"base":   {User: "admin", Port: "2222", Key: "~/.ssh/id_rsa"},
+		"prod":   {User: "deploy", ProxyJump: "bastion", Group: "base"},
+		"loop1":  {Group: "loop2"},
+		"loop2":  {Group: "loop1"},
+		"self":   {Group: "self"},
+		"broken": {Group: "missing"},
+	}
+	tests := []struct {
+		name       string
+		serverConf ReadConfig
+		want       ReadConfig
+		wantErr    bool
+	}{
+		{
+			name:       "no group",
+			serverConf: ReadConfig{Addr: "192.168.100.101"},
+			want:       ReadConfig{Addr: "192.168.100.101"},
+		},
+		{
+			name:       "group",
+			serverConf: ReadConfig{Addr: "192.168.100.101", Group: "base"},
+			want:       ReadConfig{Addr: "192.168.100.101", User: "admin", Port: "2222", Key: "~/.ssh/id_rsa", Group: "base"},
+		},
+		{
+			name:       "inherited group, nearer group is prior",
+			serverConf: ReadConfig{Addr: "192.168.100.101", Group: "prod"},
+			want:       ReadConfig{Addr: "192.168.100.101", User: "deploy", Port: "2222", Key: "~/.ssh/id_rsa", ProxyJump: "bastion", Group: "prod"},
+		},
+		{
+			name:       "server value is prior",
+			serverConf: ReadConfig{Addr: "192.168.100.101", User: "root", Port: "22", Group: "prod"},
+			want:       ReadConfig{Addr: "192.168.100.101", User: "root", Port: "22", Key: "~/.ssh/id_rsa", ProxyJump: "bastion", Group: "prod"},
+		},
+		{
+			name:       "loop",
+			serverConf: ReadConfig{Group: "loop1"},
+			wantErr:    true,
+		},
+		{
+			name:       "self loop",
+			serverConf: ReadConfig{Group: "self"},
+			wantErr:    true,
+		},
+		{
+			name:       "not found",
+			serverConf: ReadConfig{Group: "missing"},
+			wantErr:    true,
+		},
+		{
+			name:       "inherited not found",
+			serverConf: ReadConfig{Group: "broken"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyGroup(tt.serverConf, groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyGroup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Group values are expanded ($VAR) same as server values
+func TestReadConfigGroupEnv(t *testing.T) {
+	os.Setenv("LSSH_TEST_USER", "envuser")
+	defer os.Unsetenv("LSSH_TEST_USER")
+
+	config, errs := readTestConfig(t, `
+[group.prod]
+user = "${LSSH_TEST_USER}"
+
+[server.web1]
+addr  = "192.168.100.101"
+group = "prod"
+
+[server.web2]
+group = "nothing"
+`)
+	if config.Server["web1"].User != "envuser" {
+		t.Errorf("web1 user = %q, want %q", config.Server["web1"].User, "envuser")
+	}
+
+	found := false
+	for _, e := range errs {
+		if e.Server == "web2" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("config errors %v, want web2 group error", errs)
+	}
+}
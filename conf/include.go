@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Included config file (server definitions, and nested includes)
+//
+//	includes = ["~/.lssh.d/*.toml", "~/.lssh.d/inventory.json"]
+type includeFile struct {
+	Includes []string              `toml:"includes"`
+	Server   map[string]ReadConfig `toml:"server"`
+}
+
+// Get include file paths (relative path is from config file directory, glob pattern is expanded and sorted)
+func getIncludePaths(confPath string, pattern string) (paths []string, err error) {
+	pattern = expandHome(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(confPath), pattern)
+	}
+
+	paths, err = filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+	sort.Strings(paths)
+	return
+}
+
+// Merge server definitions in include files (toml, yaml or json).
+// Same server name in multiple files is error.
+func mergeIncludes(confPath string, checkConf *Config) error {
+	if checkConf.Server == nil {
+		checkConf.Server = map[string]ReadConfig{}
+	}
+
+	// server name => defined file
+	defined := map[string]string{}
+	for name := range checkConf.Server {
+		defined[name] = confPath
+	}
+	serverFiles = defined
+
+	visited := map[string]bool{confPath: true}
+	return mergeIncludeFiles(confPath, checkConf.Includes, checkConf, defined, visited)
+}
+
+func mergeIncludeFiles(confPath string, includes []string, checkConf *Config, defined map[string]string, visited map[string]bool) error {
+	for _, include := range includes {
+		paths, err := getIncludePaths(confPath, include)
+		if err != nil {
+			return fmt.Errorf("%s: %v", include, err)
+		}
+
+		for _, path := range paths {
+			// skip already read file (include loop)
+			if visited[path] {
+				continue
+			}
+			visited[path] = true
+
+			var file includeFile
+			if err := decodeConfigFile(path, &file); err != nil {
+				return err
+			}
+
+			names := []string{}
+			for name := range file.Server {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if definedPath, ok := defined[name]; ok {
+					return fmt.Errorf("%s: server %s is already defined in %s", path, name, definedPath)
+				}
+				defined[name] = path
+				checkConf.Server[name] = file.Server[name]
+			}
+
+			if err := mergeIncludeFiles(path, file.Includes, checkConf, defined, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultInventoryTtl = 300
+
+// Ignore inventory cache (--refresh-inventory)
+var refreshInventory = false
+
+// Set refresh inventory cache at next config read
+func SetRefreshInventory(refresh bool) {
+	refreshInventory = refresh
+}
+
+// Dynamic inventory providers
+//
+//	[inventory.aws]
+//	region = "ap-northeast-1"
+//	user = "ec2-user"
+type InventoryConfig struct {
+	Aws   *AwsInventory   `toml:"aws"`
+	Gcp   *GcpInventory   `toml:"gcp"`
+	Azure *AzureInventory `toml:"azure"`
+
+	Kubernetes *KubernetesInventory `toml:"kubernetes"`
+
+	// external script inventory (name => config)
+	Exec map[string]*ExecInventory `toml:"exec"`
+}
+
+// Inventory host
+type inventoryHost struct {
+	Name   string            `json:"name"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Server ReadConfig        `json:"server"`
+}
+
+// Inventory host cache (<cache dir>/inventory/<provider>.json)
+type inventoryCache struct {
+	Time  time.Time       `json:"time"`
+	Hosts []inventoryHost `json:"hosts"`
+}
+
+// Common settings of inventory provider
+type InventoryDefaults struct {
+	// server name prefix (ex. "aws:")
+	Prefix string `toml:"prefix"`
+
+	// tag (label) name to group servers. server name is "<prefix><tag value>/<name>".
+	GroupTag string `toml:"group_tag"`
+
+	// default server config values
+	User       string   `toml:"user"`
+	Port       string   `toml:"port"`
+	Key        string   `toml:"key"`
+	Identities []string `toml:"identities"`
+	ProxyJump  string   `toml:"proxy_jump"`
+
+	// ephemeral host ttl (ex. "24h", see server config "ttl")
+	Ttl string `toml:"ttl"`
+
+	// cache ttl(sec). default is 300.
+	CacheTtl int `toml:"cache_ttl"`
+}
+
+// Inventory provider
+type inventoryProvider interface {
+	// list hosts (name is without prefix)
+	getHosts() ([]inventoryHost, error)
+	getDefaults() InventoryDefaults
+}
+
+// Get enabled inventory providers (provider name => provider)
+func getInventoryProviders(inventory InventoryConfig) map[string]inventoryProvider {
+	providers := map[string]inventoryProvider{}
+	if inventory.Aws != nil {
+		providers["aws"] = inventory.Aws
+	}
+	if inventory.Gcp != nil {
+		providers["gcp"] = inventory.Gcp
+	}
+	if inventory.Azure != nil {
+		providers["azure"] = inventory.Azure
+	}
+	if inventory.Kubernetes != nil {
+		providers["kubernetes"] = inventory.Kubernetes
+	}
+	for name, provider := range inventory.Exec {
+		providers["exec."+name] = provider
+	}
+	return providers
+}
+
+// Merge dynamic inventory servers into config (lssh config value is prior).
+// If provider is failed, cached servers are used.
+func mergeInventory(checkConf *Config) (errs []error) {
+	providers := getInventoryProviders(checkConf.Inventory)
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if checkConf.Server == nil {
+		checkConf.Server = map[string]ReadConfig{}
+	}
+	for _, name := range names {
+		provider := providers[name]
+		hosts, err := getInventoryHosts(name, provider)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+
+		defaults := provider.getDefaults()
+		for _, host := range hosts {
+			// name tag can include spaces, but server name is selected by first field.
+			hostName := joinFields(host.Name)
+			if hostName == "" {
+				continue
+			}
+			serverName := defaults.Prefix + hostName
+			if group := joinFields(host.Tags[defaults.GroupTag]); defaults.GroupTag != "" && group != "" {
+				serverName = defaults.Prefix + group + "/" + hostName
+			}
+			if _, ok := checkConf.Server[serverName]; ok {
+				continue
+			}
+			// inventory tags as "key=value" server tags
+			if len(host.Server.Tags) == 0 {
+				for key, value := range host.Tags {
+					host.Server.Tags = append(host.Server.Tags, key+"="+value)
+				}
+				sort.Strings(host.Server.Tags)
+			}
+			checkConf.Server[serverName] = mergeConfig(host.Server, ReadConfig{
+				User:       defaults.User,
+				Port:       defaults.Port,
+				Key:        defaults.Key,
+				Identities: defaults.Identities,
+				ProxyJump:  defaults.ProxyJump,
+				Ttl:        defaults.Ttl,
+			})
+		}
+	}
+	return
+}
+
+// Get inventory hosts from cache (if not expired), or provider
+func getInventoryHosts(name string, provider inventoryProvider) (hosts []inventoryHost, err error) {
+	ttl := provider.getDefaults().CacheTtl
+	if ttl == 0 {
+		ttl = defaultInventoryTtl
+	}
+
+	cachePath, cacheErr := getInventoryCachePath(name)
+	var cache inventoryCache
+	if cacheErr == nil {
+		if data, readErr := ioutil.ReadFile(cachePath); readErr == nil {
+			json.Unmarshal(data, &cache)
+		}
+		if !refreshInventory && !cache.Time.IsZero() && time.Since(cache.Time) < time.Duration(ttl)*time.Second {
+			return cache.Hosts, nil
+		}
+	}
+
+	hosts, err = provider.getHosts()
+	if err != nil {
+		// use expired cache
+		return cache.Hosts, err
+	}
+
+	if cacheErr == nil {
+		data, _ := json.Marshal(inventoryCache{Time: time.Now(), Hosts: hosts})
+		ioutil.WriteFile(cachePath, data, 0600)
+	}
+	return
+}
+
+func getInventoryCachePath(name string) (path string, err error) {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return
+	}
+	dir = filepath.Join(dir, "inventory")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	path = filepath.Join(dir, name+".json")
+	return
+}
+
+// Exec inventory command (aws, gcloud ...), and decode json output
+func execInventoryCommand(result interface{}, name string, args ...string) error {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return json.Unmarshal(out, result)
+}
+
+// Replace whitespace in inventory name to "-"
+func joinFields(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
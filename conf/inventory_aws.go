@@ -0,0 +1,130 @@
+package conf
+
+import (
+	"sort"
+	"strings"
+)
+
+// AWS EC2 inventory (use aws command)
+//
+//	[inventory.aws]
+//	profile = "default"
+//	regions = ["ap-northeast-1"]
+//	filters = {"tag:env" = "prod"}
+//	address = "private"
+type AwsInventory struct {
+	InventoryDefaults
+
+	Profile string   `toml:"profile"`
+	Regions []string `toml:"regions"`
+
+	// ec2 describe-instances filters (default is running instances only)
+	Filters map[string]string `toml:"filters"`
+
+	// server name tag (default is "Name". instance id if tag is not set)
+	NameTag string `toml:"name_tag"`
+
+	// addr ("private" or "public". default is "private")
+	Address string `toml:"address"`
+}
+
+// describe-instances output
+type awsInstances struct {
+	Reservations []struct {
+		Instances []struct {
+			InstanceId       string
+			InstanceType     string
+			PrivateIpAddress string
+			PublicIpAddress  string
+			Placement        struct{ AvailabilityZone string }
+			Tags             []struct{ Key, Value string }
+		}
+	}
+}
+
+func (a *AwsInventory) getDefaults() InventoryDefaults {
+	return a.InventoryDefaults
+}
+
+func (a *AwsInventory) getHosts() (hosts []inventoryHost, err error) {
+	regions := a.Regions
+	if len(regions) == 0 {
+		// region of aws config
+		regions = []string{""}
+	}
+
+	nameTag := a.NameTag
+	if nameTag == "" {
+		nameTag = "Name"
+	}
+
+	names := map[string]bool{}
+	for _, region := range regions {
+		args := []string{"ec2", "describe-instances", "--output", "json"}
+		if a.Profile != "" {
+			args = append(args, "--profile", a.Profile)
+		}
+		if region != "" {
+			args = append(args, "--region", region)
+		}
+		args = append(args, "--filters")
+		args = append(args, a.getFilters()...)
+
+		var result awsInstances
+		if err = execInventoryCommand(&result, "aws", args...); err != nil {
+			return
+		}
+
+		for _, reservation := range result.Reservations {
+			for _, instance := range reservation.Instances {
+				tags := map[string]string{}
+				for _, tag := range instance.Tags {
+					tags[tag.Key] = tag.Value
+				}
+				name := instance.InstanceId
+				if tags[nameTag] != "" {
+					name = tags[nameTag]
+				}
+				if names[name] {
+					name = name + "-" + instance.InstanceId
+				}
+				names[name] = true
+
+				addr := instance.PrivateIpAddress
+				if a.Address == "public" {
+					addr = instance.PublicIpAddress
+				}
+				if addr == "" {
+					continue
+				}
+
+				hosts = append(hosts, inventoryHost{
+					Name: name,
+					Tags: tags,
+					Server: ReadConfig{
+						Addr: addr,
+						Note: strings.TrimSpace(strings.Join([]string{instance.InstanceId, instance.InstanceType, instance.Placement.AvailabilityZone}, " ")),
+					},
+				})
+			}
+		}
+	}
+	return
+}
+
+// Get aws cli filter args ("Name=tag:env,Values=prod")
+func (a *AwsInventory) getFilters() (filters []string) {
+	keys := []string{}
+	for key := range a.Filters {
+		keys = append(keys, key)
+	}
+	if _, ok := a.Filters["instance-state-name"]; !ok {
+		filters = append(filters, "Name=instance-state-name,Values=running")
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		filters = append(filters, "Name="+key+",Values="+a.Filters[key])
+	}
+	return
+}
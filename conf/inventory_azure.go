@@ -0,0 +1,103 @@
+package conf
+
+import (
+	"strings"
+)
+
+// Azure virtual machine inventory (use az command)
+//
+//	[inventory.azure]
+//	subscription = "my-subscription"
+//	resource_groups = ["prod-rg"]
+//	address = "private"
+type AzureInventory struct {
+	InventoryDefaults
+
+	Subscription   string   `toml:"subscription"`
+	ResourceGroups []string `toml:"resource_groups"`
+
+	// tag filter (tag name => value)
+	Tags map[string]string `toml:"tags"`
+
+	// addr ("private" or "public". default is "private")
+	Address string `toml:"address"`
+}
+
+// vm list --show-details output
+type azureVms []struct {
+	Name            string
+	Location        string
+	ResourceGroup   string
+	PowerState      string
+	PrivateIps      string
+	PublicIps       string
+	Tags            map[string]string
+	HardwareProfile struct{ VmSize string }
+}
+
+func (a *AzureInventory) getDefaults() InventoryDefaults {
+	return a.InventoryDefaults
+}
+
+func (a *AzureInventory) getHosts() (hosts []inventoryHost, err error) {
+	resourceGroups := a.ResourceGroups
+	if len(resourceGroups) == 0 {
+		// all resource groups
+		resourceGroups = []string{""}
+	}
+
+	names := map[string]bool{}
+	for _, resourceGroup := range resourceGroups {
+		args := []string{"vm", "list", "--show-details", "--output", "json"}
+		if a.Subscription != "" {
+			args = append(args, "--subscription", a.Subscription)
+		}
+		if resourceGroup != "" {
+			args = append(args, "--resource-group", resourceGroup)
+		}
+
+		var result azureVms
+		if err = execInventoryCommand(&result, "az", args...); err != nil {
+			return
+		}
+
+	vms:
+		for _, vm := range result {
+			if vm.PowerState != "VM running" {
+				continue
+			}
+			for key, value := range a.Tags {
+				if vm.Tags[key] != value {
+					continue vms
+				}
+			}
+
+			// comma separated ip list
+			ips := vm.PrivateIps
+			if a.Address == "public" {
+				ips = vm.PublicIps
+			}
+			addr := strings.TrimSpace(strings.Split(ips, ",")[0])
+			if addr == "" {
+				continue
+			}
+
+			// vm name is unique in resource group
+			name := vm.Name
+			if names[name] {
+				name = name + "-" + vm.ResourceGroup
+			}
+			names[name] = true
+
+			hosts = append(hosts, inventoryHost{
+				Name: name,
+				Tags: vm.Tags,
+				Server: ReadConfig{
+					Addr: addr,
+					Note: strings.TrimSpace(strings.Join([]string{vm.HardwareProfile.VmSize, vm.Location, vm.ResourceGroup}, " ")),
+				},
+			})
+		}
+	}
+	return
+}
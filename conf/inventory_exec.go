@@ -0,0 +1,65 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// External script inventory (json list of server objects at stdout)
+//
+//	[inventory.exec.cmdb]
+//	inventory_cmd = "~/bin/cmdb-inventory.sh"
+//
+//	# output
+//	[{"name": "web1", "addr": "192.168.100.101", "user": "root", "tags": {"env": "prod"}}]
+type ExecInventory struct {
+	InventoryDefaults
+
+	InventoryCmd string `toml:"inventory_cmd"`
+}
+
+func (e *ExecInventory) getDefaults() InventoryDefaults {
+	return e.InventoryDefaults
+}
+
+func (e *ExecInventory) getHosts() (hosts []inventoryHost, err error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("/bin/sh", "-c", expandHome(e.InventoryCmd))
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("inventory_cmd error: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var objects []map[string]interface{}
+	if err = json.Unmarshal(out, &objects); err != nil {
+		return nil, fmt.Errorf("inventory_cmd output: %v", err)
+	}
+
+	for i, object := range objects {
+		name, _ := object["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("inventory_cmd output: server %d has no name", i)
+		}
+		delete(object, "name")
+
+		// tags (tag name => value, for group_tag)
+		tags := map[string]string{}
+		if values, ok := object["tags"].(map[string]interface{}); ok {
+			for key, value := range values {
+				tags[key] = fmt.Sprint(value)
+			}
+			delete(object, "tags")
+		}
+
+		var serverConf ReadConfig
+		if err = decodeMap(object, &serverConf); err != nil {
+			return nil, fmt.Errorf("inventory_cmd output: %s: %v", name, err)
+		}
+		hosts = append(hosts, inventoryHost{Name: name, Tags: tags, Server: serverConf})
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package conf
+
+import (
+	"path"
+	"strings"
+)
+
+// GCP Compute Engine inventory (use gcloud command)
+//
+//	[inventory.gcp]
+//	projects = ["my-project"]
+//	filter = "labels.env=prod"
+//	address = "internal"
+type GcpInventory struct {
+	InventoryDefaults
+
+	Projects []string `toml:"projects"`
+
+	// gcloud compute instances list filter (running instances only)
+	Filter string `toml:"filter"`
+
+	// addr ("internal" or "external". default is "internal")
+	Address string `toml:"address"`
+}
+
+// compute instances list output
+type gcpInstances []struct {
+	Name              string
+	Zone              string
+	MachineType       string
+	Labels            map[string]string
+	NetworkInterfaces []struct {
+		NetworkIP     string
+		AccessConfigs []struct{ NatIP string }
+	}
+}
+
+func (g *GcpInventory) getDefaults() InventoryDefaults {
+	return g.InventoryDefaults
+}
+
+func (g *GcpInventory) getHosts() (hosts []inventoryHost, err error) {
+	projects := g.Projects
+	if len(projects) == 0 {
+		// project of gcloud config
+		projects = []string{""}
+	}
+
+	filter := "status=RUNNING"
+	if g.Filter != "" {
+		filter = filter + " AND (" + g.Filter + ")"
+	}
+
+	names := map[string]bool{}
+	for _, project := range projects {
+		args := []string{"compute", "instances", "list", "--format", "json", "--filter", filter}
+		if project != "" {
+			args = append(args, "--project", project)
+		}
+
+		var result gcpInstances
+		if err = execInventoryCommand(&result, "gcloud", args...); err != nil {
+			return
+		}
+
+		for _, instance := range result {
+			addr := ""
+			if len(instance.NetworkInterfaces) > 0 {
+				networkInterface := instance.NetworkInterfaces[0]
+				addr = networkInterface.NetworkIP
+				if g.Address == "external" {
+					addr = ""
+					if len(networkInterface.AccessConfigs) > 0 {
+						addr = networkInterface.AccessConfigs[0].NatIP
+					}
+				}
+			}
+			if addr == "" {
+				continue
+			}
+
+			// instance name is unique in project
+			name := instance.Name
+			if names[name] && project != "" {
+				name = name + "-" + project
+			}
+			names[name] = true
+
+			hosts = append(hosts, inventoryHost{
+				Name: name,
+				Tags: instance.Labels,
+				Server: ReadConfig{
+					Addr: addr,
+					Note: strings.TrimSpace(strings.Join([]string{path.Base(instance.MachineType), path.Base(instance.Zone), project}, " ")),
+				},
+			})
+		}
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package conf
+
+import (
+	"strings"
+)
+
+// Kubernetes node inventory (use kubectl command)
+//
+//	[inventory.kubernetes]
+//	context = "prod-cluster"
+//	selector = "node-role.kubernetes.io/worker"
+//	address = "internal"
+type KubernetesInventory struct {
+	InventoryDefaults
+
+	Kubeconfig string `toml:"kubeconfig"`
+	Context    string `toml:"context"`
+
+	// node label selector (kubectl -l)
+	Selector string `toml:"selector"`
+
+	// addr ("internal" or "external". default is "internal")
+	Address string `toml:"address"`
+}
+
+// get nodes output
+type kubernetesNodes struct {
+	Items []struct {
+		Metadata struct {
+			Name   string
+			Labels map[string]string
+		}
+		Status struct {
+			Addresses []struct{ Type, Address string }
+			NodeInfo  struct{ OsImage, KubeletVersion string }
+		}
+	}
+}
+
+func (k *KubernetesInventory) getDefaults() InventoryDefaults {
+	return k.InventoryDefaults
+}
+
+func (k *KubernetesInventory) getHosts() (hosts []inventoryHost, err error) {
+	args := []string{"get", "nodes", "--output", "json"}
+	if k.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", expandHome(k.Kubeconfig))
+	}
+	if k.Context != "" {
+		args = append(args, "--context", k.Context)
+	}
+	if k.Selector != "" {
+		args = append(args, "--selector", k.Selector)
+	}
+
+	var result kubernetesNodes
+	if err = execInventoryCommand(&result, "kubectl", args...); err != nil {
+		return
+	}
+
+	addressType := "InternalIP"
+	if k.Address == "external" {
+		addressType = "ExternalIP"
+	}
+	for _, node := range result.Items {
+		addr := ""
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType {
+				addr = address.Address
+				break
+			}
+		}
+		if addr == "" {
+			continue
+		}
+
+		hosts = append(hosts, inventoryHost{
+			Name: node.Metadata.Name,
+			Tags: node.Metadata.Labels,
+			Server: ReadConfig{
+				Addr: addr,
+				Note: strings.TrimSpace(node.Status.NodeInfo.OsImage + " " + node.Status.NodeInfo.KubeletVersion),
+			},
+		})
+	}
+	return
+}
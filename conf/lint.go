@@ -0,0 +1,102 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Lint finding severity
+const (
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+var severityLevels = map[string]int{SeverityInfo: 0, SeverityWarn: 1, SeverityError: 2}
+
+// Lint finding
+type LintFinding struct {
+	Severity string `json:"severity"`
+	Server   string `json:"server,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Check severity is over level ("warn", "error")
+func (f LintFinding) IsOver(level string) bool {
+	return severityLevels[f.Severity] >= severityLevels[level]
+}
+
+// Check severity name
+func CheckSeverity(level string) error {
+	if _, ok := severityLevels[level]; !ok {
+		return fmt.Errorf("severity %s is not valid (info, warn, error)", level)
+	}
+	return nil
+}
+
+// Lint config for security smells
+// (plaintext password, host key check disabled, key file permission, plaintext secret transport)
+func Lint(confPath string, listConf Config) (findings []LintFinding) {
+	names := GetNameList(listConf)
+	sort.Strings(names)
+
+	passwordCount := 0
+	noCheckServers := []string{}
+	checkedKeys := map[string]bool{}
+	for _, name := range names {
+		serverConf := listConf.Server[name]
+
+		if serverConf.Pass != "" && !IsSecretRef(serverConf.Pass) {
+			passwordCount++
+			findings = append(findings, LintFinding{Severity: SeverityWarn, Server: name, Message: "plaintext password in config (use password_cmd or vault)"})
+		}
+		if serverConf.Passphrase != "" && !IsSecretRef(serverConf.Passphrase) {
+			passwordCount++
+			findings = append(findings, LintFinding{Severity: SeverityWarn, Server: name, Message: "plaintext passphrase in config (use passphrase_cmd, keychain or vault)"})
+		}
+
+		switch strings.ToLower(serverConf.StrictHostKeyChecking) {
+		case "", "no", "off":
+			noCheckServers = append(noCheckServers, name)
+		}
+
+		// key file permission
+		keys := append([]string{serverConf.Key}, serverConf.Identities...)
+		for _, key := range keys {
+			if key == "" || checkedKeys[key] {
+				continue
+			}
+			checkedKeys[key] = true
+			if info, err := os.Stat(expandHome(key)); err == nil && info.Mode().Perm()&0077 != 0 {
+				findings = append(findings, LintFinding{Severity: SeverityError, Server: name, Message: fmt.Sprintf("key file %s is accessible by other users (%s)", key, info.Mode().Perm())})
+			}
+		}
+	}
+
+	// host key check
+	if len(names) > 0 && len(noCheckServers) == len(names) {
+		findings = append(findings, LintFinding{Severity: SeverityError, Message: "host key check is disabled for all servers (set strict_host_key_checking)"})
+	} else {
+		for _, name := range noCheckServers {
+			findings = append(findings, LintFinding{Severity: SeverityWarn, Server: name, Message: "host key check is disabled"})
+		}
+	}
+
+	// config file permission (with plaintext password)
+	if info, err := os.Stat(confPath); err == nil && passwordCount > 0 && info.Mode().Perm()&0077 != 0 {
+		findings = append(findings, LintFinding{Severity: SeverityError, Message: fmt.Sprintf("config file with plaintext password is accessible by other users (%s)", info.Mode().Perm())})
+	}
+
+	// secret over plaintext http
+	vault := listConf.Secrets.Vault
+	addr := vault.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if strings.HasPrefix(addr, "http://") {
+		findings = append(findings, LintFinding{Severity: SeverityError, Message: fmt.Sprintf("vault addr %s is plaintext http", addr)})
+	}
+	return
+}
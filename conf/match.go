@@ -0,0 +1,95 @@
+package conf
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Match block (like OpenSSH ssh_config `Match`)
+//
+//	[[match]]
+//	host = "*.prod !db*.prod"
+//	user = "deploy"
+//	exec = "test -f ~/.vpn_up"
+//	[match.set]
+//	key = "~/.ssh/deploy_key"
+type MatchConfig struct {
+	Host string     `toml:"host"`
+	User string     `toml:"user"`
+	Exec string     `toml:"exec"`
+	Set  ReadConfig `toml:"set"`
+}
+
+// Apply match blocks to server config.
+// Same as ssh_config, the first obtained value for each field is used.
+func applyMatch(serverName string, serverConf ReadConfig, matches []MatchConfig) ReadConfig {
+	for _, match := range matches {
+		if !checkMatch(serverName, serverConf, match) {
+			continue
+		}
+		serverConf = mergeConfig(serverConf, match.Set)
+	}
+	return serverConf
+}
+
+// Check match block predicates (host, user, exec)
+func checkMatch(serverName string, serverConf ReadConfig, match MatchConfig) bool {
+	if match.Host != "" && !matchPatternList(match.Host, serverName, serverConf.Addr) {
+		return false
+	}
+	if match.User != "" && !matchPatternList(match.User, serverConf.User) {
+		return false
+	}
+	if match.Exec != "" {
+		// %n: server name, %h: addr, %p: port, %r: user
+		replacer := strings.NewReplacer("%n", serverName, "%h", serverConf.Addr, "%p", serverConf.Port, "%r", serverConf.User, "%%", "%")
+		if err := exec.Command("/bin/sh", "-c", replacer.Replace(match.Exec)).Run(); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Match glob pattern list ("a* b*,c !d"). Negated pattern match is always false.
+func matchPatternList(patternList string, values ...string) (result bool) {
+	patterns := strings.FieldsFunc(patternList, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		for _, value := range values {
+			if ok, _ := filepath.Match(pattern, value); ok {
+				if negate {
+					return false
+				}
+				result = true
+			}
+		}
+	}
+	return
+}
+
+// Set src field value to dst empty field
+func mergeConfig(dst ReadConfig, src ReadConfig) ReadConfig {
+	dstValue := reflect.ValueOf(&dst).Elem()
+	srcValue := reflect.ValueOf(src)
+	for i := 0; i < dstValue.NumField(); i++ {
+		field := dstValue.Field(i)
+		if isZeroValue(field) && !isZeroValue(srcValue.Field(i)) {
+			field.Set(srcValue.Field(i))
+		}
+	}
+	return dst
+}
+
+func isZeroValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
@@ -0,0 +1,210 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPatternList(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternList string
+		values      []string
+		want        bool
+	}{
+		{"star", "*.prod", []string{"web1.prod"}, true},
+		{"star not match", "*.prod", []string{"web1.dev"}, false},
+		{"question", "web?", []string{"web1"}, true},
+		{"question one char", "web?", []string{"web10"}, false},
+		{"space list", "*.dev *.prod", []string{"db1.prod"}, true},
+		{"comma list", "*.dev,*.prod", []string{"db1.prod"}, true},
+		{"comma and space list", "a*, b*", []string{"bx"}, true},
+		{"negate", "*.prod !db*.prod", []string{"web1.prod"}, true},
+		{"negated match", "*.prod !db*.prod", []string{"db1.prod"}, false},
+		{"negate before pattern", "!db*.prod *.prod", []string{"db1.prod"}, false},
+		{"negate only", "!db*", []string{"web1"}, false},
+		{"second value", "192.168.*", []string{"web1", "192.168.100.101"}, true},
+		{"negated second value", "web* !192.168.*", []string{"web1", "192.168.100.101"}, false},
+		{"empty value", "*", []string{""}, true},
+		{"bad pattern", "[", []string{"["}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPatternList(tt.patternList, tt.values...); got != tt.want {
+				t.Errorf("matchPatternList(%q, %q) = %v, want %v", tt.patternList, tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMatch(t *testing.T) {
+	serverConf := ReadConfig{Addr: "192.168.100.101", Port: "22", User: "deploy"}
+	tests := []struct {
+		name  string
+		match MatchConfig
+		want  bool
+	}{
+		{"empty", MatchConfig{}, true},
+		{"host by name", MatchConfig{Host: "web*"}, true},
+		{"host by addr", MatchConfig{Host: "192.168.100.*"}, true},
+		{"host not match", MatchConfig{Host: "db*"}, false},
+		{"user", MatchConfig{User: "deploy"}, true},
+		{"user not match", MatchConfig{User: "root"}, false},
+		{"host and user", MatchConfig{Host: "web*", User: "root"}, false},
+		{"exec true", MatchConfig{Exec: "true"}, true},
+		{"exec false", MatchConfig{Exec: "false"}, false},
+		{"exec token", MatchConfig{Exec: `test "%n %h %p %r %%" = "web1 192.168.100.101 22 deploy %"`}, true},
+		{"exec not run at host not match", MatchConfig{Host: "db*", Exec: "true"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkMatch("web1", serverConf, tt.match); got != tt.want {
+				t.Errorf("checkMatch(%+v) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMatch(t *testing.T) {
+	matches := []MatchConfig{
+		{Host: "db*", Set: ReadConfig{User: "dba"}},
+		{Host: "*.prod", Set: ReadConfig{User: "deploy", Key: "~/.ssh/prod_key"}},
+		{Host: "*", Set: ReadConfig{User: "other", Key: "~/.ssh/id_rsa", Port: "2222"}},
+	}
+	tests := []struct {
+		name       string
+		serverName string
+		serverConf ReadConfig
+		want       ReadConfig
+	}{
+		{
+			name:       "first value is used",
+			serverName: "web1.prod",
+			want:       ReadConfig{User: "deploy", Key: "~/.ssh/prod_key", Port: "2222"},
+		},
+		{
+			name:       "server value is prior",
+			serverName: "web1.prod",
+			serverConf: ReadConfig{User: "root"},
+			want:       ReadConfig{User: "root", Key: "~/.ssh/prod_key", Port: "2222"},
+		},
+		{
+			name:       "first match block",
+			serverName: "db1.prod",
+			want:       ReadConfig{User: "dba", Key: "~/.ssh/prod_key", Port: "2222"},
+		},
+		{
+			name:       "not match block is skipped",
+			serverName: "web1.dev",
+			want:       ReadConfig{User: "other", Key: "~/.ssh/id_rsa", Port: "2222"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyMatch(tt.serverName, tt.serverConf, matches)
+			if got.User != tt.want.User || got.Key != tt.want.Key || got.Port != tt.want.Port {
+				t.Errorf("applyMatch(%q) = user:%q key:%q port:%q, want user:%q key:%q port:%q",
+					tt.serverName, got.User, got.Key, got.Port, tt.want.User, tt.want.Key, tt.want.Port)
+			}
+		})
+	}
+}
+
+// Write config file to temp dir, and read it
+func readTestConfig(t *testing.T, data string) (Config, []ConfigError) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "lssh-conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lssh.conf")
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, errs, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config, errs
+}
+
+// Precedence: server config > group defaults > match blocks
+func TestReadConfigMatchPrecedence(t *testing.T) {
+	config, errs := readTestConfig(t, `
+[group.prod]
+user = "deploy"
+port = "2200"
+
+[[match]]
+host = "*.prod !db*"
+[match.set]
+user = "match-user"
+key  = "~/.ssh/prod_key"
+port = "2222"
+
+[[match]]
+user = "deploy"
+[match.set]
+color = "#300000"
+
+[server."web1.prod"]
+addr  = "192.168.100.101"
+group = "prod"
+
+[server."web2.prod"]
+addr  = "192.168.100.102"
+user  = "root"
+group = "prod"
+
+[server."web3.prod"]
+addr  = "192.168.100.103:2022"
+group = "prod"
+
+[server."db1.prod"]
+addr = "192.168.100.201"
+user = "dba"
+pass = "password"
+
+[server."web1.dev"]
+addr = "192.168.200.101"
+user = "dev"
+pass = "password"
+`)
+	if len(errs) != 0 {
+		t.Fatalf("config errors: %v", errs)
+	}
+
+	tests := []struct {
+		server string
+		user   string
+		port   string
+		key    string
+		color  string
+	}{
+		// group is prior to match, and match user predicate sees group user
+		{"web1.prod", "deploy", "2200", "~/.ssh/prod_key", "#300000"},
+		// server is prior to group
+		{"web2.prod", "root", "2200", "~/.ssh/prod_key", ""},
+		// addr port is prior to group port
+		{"web3.prod", "deploy", "2022", "~/.ssh/prod_key", "#300000"},
+		// negated host pattern
+		{"db1.prod", "dba", DefaultPort, "", ""},
+		{"web1.dev", "dev", DefaultPort, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			v := config.Server[tt.server]
+			if v.User != tt.user || v.Port != tt.port || v.Key != tt.key || v.Color != tt.color {
+				t.Errorf("%s = user:%q port:%q key:%q color:%q, want user:%q port:%q key:%q color:%q",
+					tt.server, v.User, v.Port, v.Key, v.Color, tt.user, tt.port, tt.key, tt.color)
+			}
+		})
+	}
+}
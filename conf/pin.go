@@ -0,0 +1,49 @@
+package conf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Max number of pinned servers (number key 1-9, 0)
+const MaxPins = 10
+
+// Get pin number key of pinned servers ("1" ... "9", "0")
+func GetPinKeys(listConf Config) (pinKeys map[string]string) {
+	pinKeys = map[string]string{}
+	for i, name := range listConf.UI.Pins {
+		if i >= MaxPins {
+			break
+		}
+		pinKeys[name] = strconv.Itoa((i + 1) % 10)
+	}
+	return
+}
+
+// Get pinned server name from "@N" (lssh @3)
+func GetPinName(listConf Config, arg string) (name string, ok bool) {
+	if !strings.HasPrefix(arg, "@") {
+		return "", false
+	}
+	for name, key := range GetPinKeys(listConf) {
+		if key == arg[1:] {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Check pinned servers exist
+func checkPins(checkConf Config) (errs []ConfigError) {
+	if len(checkConf.UI.Pins) > MaxPins {
+		errs = append(errs, newConfigError("", "ui: 'pins' is up to %d servers.", MaxPins))
+	}
+	for _, name := range checkConf.UI.Pins {
+		_, isServer := checkConf.Server[name]
+		_, isTemplate := checkConf.Template[name]
+		if !isServer && !isTemplate {
+			errs = append(errs, newConfigError("", "ui: 'pins' server %s not found.", name))
+		}
+	}
+	return
+}
@@ -0,0 +1,78 @@
+package conf
+
+import (
+	"fmt"
+)
+
+// Crypto algorithm lists
+type CryptoPolicy struct {
+	Ciphers           []string
+	Macs              []string
+	KexAlgorithms     []string
+	HostKeyAlgorithms []string
+}
+
+// Named crypto policy ("policy" in server config)
+var cryptoPolicies = map[string]CryptoPolicy{
+	// Modern algorithms only (AEAD ciphers, curve25519, ed25519)
+	"modern": {
+		Ciphers:           []string{"chacha20-poly1305@openssh.com", "aes256-gcm@openssh.com", "aes128-gcm@openssh.com"},
+		Macs:              []string{"hmac-sha2-512-etm@openssh.com", "hmac-sha2-256-etm@openssh.com"},
+		KexAlgorithms:     []string{"curve25519-sha256", "curve25519-sha256@libssh.org"},
+		HostKeyAlgorithms: []string{"ssh-ed25519", "ecdsa-sha2-nistp256", "rsa-sha2-512", "rsa-sha2-256"},
+	},
+
+	// FIPS 140 approved algorithms (AES, SHA-2, NIST curves)
+	"fips": {
+		Ciphers:           []string{"aes256-gcm@openssh.com", "aes128-gcm@openssh.com", "aes256-ctr", "aes192-ctr", "aes128-ctr"},
+		Macs:              []string{"hmac-sha2-512-etm@openssh.com", "hmac-sha2-256-etm@openssh.com", "hmac-sha2-512", "hmac-sha2-256"},
+		KexAlgorithms:     []string{"ecdh-sha2-nistp521", "ecdh-sha2-nistp384", "ecdh-sha2-nistp256", "diffie-hellman-group16-sha512", "diffie-hellman-group14-sha256"},
+		HostKeyAlgorithms: []string{"ecdsa-sha2-nistp521", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp256", "rsa-sha2-512", "rsa-sha2-256"},
+	},
+
+	// Default algorithms and old algorithms (for old servers and network devices)
+	"legacy": {
+		Ciphers: []string{
+			"chacha20-poly1305@openssh.com", "aes256-gcm@openssh.com", "aes128-gcm@openssh.com",
+			"aes256-ctr", "aes192-ctr", "aes128-ctr", "aes128-cbc", "3des-cbc",
+		},
+		Macs: []string{
+			"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com",
+			"hmac-sha2-256", "hmac-sha2-512", "hmac-sha1", "hmac-sha1-96",
+		},
+		KexAlgorithms: []string{
+			"curve25519-sha256", "curve25519-sha256@libssh.org", "ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+			"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512", "diffie-hellman-group14-sha1", "diffie-hellman-group1-sha1",
+		},
+		HostKeyAlgorithms: []string{
+			"ssh-ed25519", "ecdsa-sha2-nistp256", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521",
+			"rsa-sha2-512", "rsa-sha2-256", "ssh-rsa", "ssh-dss",
+		},
+	},
+}
+
+// Get crypto algorithm lists of server (policy, and per field override).
+// Empty list is default algorithms.
+func GetCryptoPolicy(serverConf ReadConfig) (policy CryptoPolicy, err error) {
+	if serverConf.Policy != "" {
+		var ok bool
+		if policy, ok = cryptoPolicies[serverConf.Policy]; !ok {
+			err = fmt.Errorf("'policy' %s is not found (modern, fips, legacy)", serverConf.Policy)
+			return
+		}
+	}
+
+	if len(serverConf.Ciphers) > 0 {
+		policy.Ciphers = serverConf.Ciphers
+	}
+	if len(serverConf.Macs) > 0 {
+		policy.Macs = serverConf.Macs
+	}
+	if len(serverConf.KexAlgorithms) > 0 {
+		policy.KexAlgorithms = serverConf.KexAlgorithms
+	}
+	if len(serverConf.HostKeyAlgorithms) > 0 {
+		policy.HostKeyAlgorithms = serverConf.HostKeyAlgorithms
+	}
+	return
+}
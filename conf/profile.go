@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+)
+
+var profileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Current workspace profile (default: $LSSH_PROFILE)
+var currentProfile = os.Getenv("LSSH_PROFILE")
+
+// Set workspace profile
+func SetProfile(name string) error {
+	if name != "" && !profileNameRegexp.MatchString(name) {
+		return fmt.Errorf("profile name %s is not valid", name)
+	}
+	currentProfile = name
+	return nil
+}
+
+// Get workspace profile name
+func GetProfile() string {
+	return currentProfile
+}
+
+// Get profile root directory ($XDG_CONFIG_HOME/lssh/profiles/<name>)
+func GetProfileDir() (dir string, err error) {
+	if !profileNameRegexp.MatchString(currentProfile) {
+		err = fmt.Errorf("profile name %s is not valid", currentProfile)
+		return
+	}
+
+	dir = filepath.Join(getConfigBaseDir(), "profiles", currentProfile)
+	err = os.MkdirAll(dir, 0700)
+	return
+}
+
+// Get default config file path (~/.lssh.conf or ~/.lssh.yaml, or profile config file)
+func GetDefaultConfPath() string {
+	if currentProfile != "" {
+		if dir, err := GetProfileDir(); err == nil {
+			return getConfPathInDir(dir, "lssh")
+		}
+	}
+
+	usr, _ := user.Current()
+	return getConfPathInDir(usr.HomeDir, ".lssh")
+}
+
+// Get known_hosts file path of profile. If profile is not set, return empty string.
+func GetProfileKnownHosts() string {
+	if currentProfile == "" {
+		return ""
+	}
+	dir, err := GetProfileDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "known_hosts")
+}
@@ -0,0 +1,48 @@
+package conf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse rekey limit (same as OpenSSH RekeyLimit, ex. "1G", "500M 1h", "default 30m")
+func ParseRekeyLimit(value string) (data int64, interval time.Duration, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		err = fmt.Errorf("'rekey_limit' %s is not valid", value)
+		return
+	}
+
+	// data size (K, M, G)
+	if fields[0] != "default" {
+		size := strings.ToUpper(fields[0])
+		unit := int64(1)
+		switch {
+		case strings.HasSuffix(size, "K"):
+			unit = 1 << 10
+		case strings.HasSuffix(size, "M"):
+			unit = 1 << 20
+		case strings.HasSuffix(size, "G"):
+			unit = 1 << 30
+		}
+		num, numErr := strconv.ParseInt(strings.TrimRight(size, "KMG"), 10, 64)
+		if numErr != nil || num < 1 {
+			err = fmt.Errorf("'rekey_limit' data size %s is not valid", fields[0])
+			return
+		}
+		data = num * unit
+	}
+
+	// time interval (seconds, or "1h", "30m")
+	if len(fields) == 2 && fields[1] != "none" {
+		if sec, secErr := strconv.Atoi(fields[1]); secErr == nil {
+			interval = time.Duration(sec) * time.Second
+		} else if interval, err = time.ParseDuration(fields[1]); err != nil {
+			err = fmt.Errorf("'rekey_limit' time %s is not valid", fields[1])
+			return
+		}
+	}
+	return
+}
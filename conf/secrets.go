@@ -0,0 +1,227 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const vaultSecretPrefix = "vault:"
+
+// Secrets provider config
+//
+//	[secrets.vault]
+//	addr = "https://vault.example.com:8200"
+//	role_id = "..."
+//	secret_id_file = "~/.vault-secret-id"
+type SecretsConfig struct {
+	Vault VaultConfig `toml:"vault"`
+	Age   AgeConfig   `toml:"age"`
+}
+
+// HashiCorp Vault (token or approle auth)
+type VaultConfig struct {
+	// default is $VAULT_ADDR
+	Addr      string `toml:"addr"`
+	Namespace string `toml:"namespace"`
+
+	// token auth (default is $VAULT_TOKEN, or ~/.vault-token)
+	Token     string `toml:"token"`
+	TokenFile string `toml:"token_file"`
+
+	// approle auth
+	RoleId       string `toml:"role_id"`
+	SecretId     string `toml:"secret_id"`
+	SecretIdFile string `toml:"secret_id_file"`
+	ApprolePath  string `toml:"approle_path"`
+}
+
+// Cache of vault token and read secrets
+var vaultCache = struct {
+	sync.Mutex
+	token string
+	data  map[string]map[string]interface{}
+}{data: map[string]map[string]interface{}{}}
+
+// Check value is secret reference ("vault:secret/path#field"), or encrypted value (age, gpg)
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, vaultSecretPrefix) || IsEncrypted(value)
+}
+
+// Resolve secret reference, or decrypt encrypted value. Not reference value is returned as is.
+func ResolveSecret(value string, secrets SecretsConfig) (string, error) {
+	if !IsSecretRef(value) {
+		return value, nil
+	}
+
+	if IsEncrypted(value) {
+		data, err := decrypt([]byte(strings.TrimSpace(value)+"\n"), secrets.Age)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	ref := strings.TrimPrefix(value, vaultSecretPrefix)
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return "", fmt.Errorf("%s: field is not specified (vault:path#field)", value)
+	}
+	path, field := strings.Trim(ref[:i], "/"), ref[i+1:]
+
+	data, err := readVaultSecret(secrets.Vault, path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", value, err)
+	}
+	secret, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: field %s is not found", value, field)
+	}
+	return secret, nil
+}
+
+// Read vault secret data (KV v1 and v2)
+func readVaultSecret(vault VaultConfig, path string) (map[string]interface{}, error) {
+	vaultCache.Lock()
+	defer vaultCache.Unlock()
+
+	if data, ok := vaultCache.data[path]; ok {
+		return data, nil
+	}
+
+	token, err := getVaultToken(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := vaultRequest(vault, "GET", path, token, nil, &result); err != nil {
+		return nil, err
+	}
+
+	// KV v2 ({"data": {"data": {...}, "metadata": {...}}})
+	data := result.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	vaultCache.data[path] = data
+	return data, nil
+}
+
+// Get vault token (token, token file, $VAULT_TOKEN, approle login or ~/.vault-token)
+func getVaultToken(vault VaultConfig) (string, error) {
+	if vaultCache.token != "" {
+		return vaultCache.token, nil
+	}
+
+	token := vault.Token
+	switch {
+	case token != "":
+	case vault.TokenFile != "":
+		data, err := ioutil.ReadFile(expandHome(vault.TokenFile))
+		if err != nil {
+			return "", err
+		}
+		token = strings.TrimSpace(string(data))
+	case os.Getenv("VAULT_TOKEN") != "":
+		token = os.Getenv("VAULT_TOKEN")
+	case vault.RoleId != "":
+		var err error
+		if token, err = vaultApproleLogin(vault); err != nil {
+			return "", err
+		}
+	default:
+		data, err := ioutil.ReadFile(expandHome("~/.vault-token"))
+		if err != nil {
+			return "", fmt.Errorf("vault token is not set")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	vaultCache.token = token
+	return token, nil
+}
+
+// Login with approle, and get client token
+func vaultApproleLogin(vault VaultConfig) (string, error) {
+	secretId := vault.SecretId
+	if secretId == "" && vault.SecretIdFile != "" {
+		data, err := ioutil.ReadFile(expandHome(vault.SecretIdFile))
+		if err != nil {
+			return "", err
+		}
+		secretId = strings.TrimSpace(string(data))
+	}
+
+	approlePath := vault.ApprolePath
+	if approlePath == "" {
+		approlePath = "approle"
+	}
+
+	body, _ := json.Marshal(map[string]string{"role_id": vault.RoleId, "secret_id": secretId})
+	var result struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := vaultRequest(vault, "POST", "auth/"+strings.Trim(approlePath, "/")+"/login", "", body, &result); err != nil {
+		return "", fmt.Errorf("approle login: %v", err)
+	}
+	return result.Auth.ClientToken, nil
+}
+
+// Send vault api request, and decode json response
+func vaultRequest(vault VaultConfig, method string, path string, token string, body []byte, result interface{}) error {
+	addr := vault.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return fmt.Errorf("vault addr is not set")
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(addr, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	namespace := vault.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(data, &vaultErr)
+		return fmt.Errorf("%s (%s)", resp.Status, strings.Join(vaultErr.Errors, ", "))
+	}
+	return json.Unmarshal(data, result)
+}
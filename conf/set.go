@@ -0,0 +1,84 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Short name of option (for --set)
+var setOptionAlias = map[string]string{
+	"proxy":    "proxy_jump",
+	"jump":     "proxy_jump",
+	"identity": "identities",
+	"timeout":  "connect_timeout",
+}
+
+// Override server config value by "key=value" (key is toml key name)
+func SetOption(serverConf ReadConfig, option string) (ReadConfig, error) {
+	i := strings.Index(option, "=")
+	if i < 0 {
+		return serverConf, fmt.Errorf("--set %s: format is key=value", option)
+	}
+	key := strings.TrimSpace(option[:i])
+	value := strings.TrimSpace(option[i+1:])
+	if alias, ok := setOptionAlias[key]; ok {
+		key = alias
+	}
+
+	v := reflect.ValueOf(&serverConf).Elem()
+	t := v.Type()
+	for n := 0; n < t.NumField(); n++ {
+		if t.Field(n).Tag.Get("toml") != key {
+			continue
+		}
+		if err := setValue(v.Field(n), value); err != nil {
+			return serverConf, fmt.Errorf("--set %s: %v", option, err)
+		}
+		if key == "port" {
+			if err := CheckPort(value); err != nil {
+				return serverConf, fmt.Errorf("--set %s: %v", option, err)
+			}
+		}
+		return serverConf, nil
+	}
+	return serverConf, fmt.Errorf("--set %s: unknown option %s", option, key)
+}
+
+// Set string value to config field
+func setValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		num, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(num))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Ptr:
+		ptr := reflect.New(field.Type().Elem())
+		if err := setValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+	case reflect.Slice:
+		list := []string{}
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("cannot set %s value", field.Kind())
+	}
+	return nil
+}
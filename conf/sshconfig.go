@@ -0,0 +1,173 @@
+package conf
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Default identity files (same as ssh)
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// Import OpenSSH config (~/.ssh/config) hosts
+//
+//	[include.sshconfig]
+//	path = "~/.ssh/config"
+type SshConfigInclude struct {
+	Path string `toml:"path"`
+}
+
+type IncludeConfig struct {
+	SshConfig *SshConfigInclude `toml:"sshconfig"`
+}
+
+// ssh_config Host section
+type sshConfigHost struct {
+	patterns string
+	options  map[string][]string
+}
+
+// Get exist default identity files (~/.ssh/id_ed25519, ...)
+func GetDefaultIdentities() (identities []string) {
+	usr, _ := user.Current()
+	for _, name := range defaultIdentityFiles {
+		path := filepath.Join(usr.HomeDir, ".ssh", name)
+		if _, err := os.Stat(path); err == nil {
+			identities = append(identities, path)
+		}
+	}
+	return
+}
+
+// Parse ssh_config file (Host sections only, Match section is skipped)
+func parseSshConfig(path string) (hosts []sshConfigHost, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var current *sshConfigHost
+	skip := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// "Key value" or "Key=value"
+		i := strings.IndexAny(line, " \t=")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(line[:i])
+		value := strings.Trim(strings.TrimSpace(strings.TrimLeft(line[i:], " \t=")), "\"")
+
+		switch key {
+		case "host":
+			hosts = append(hosts, sshConfigHost{patterns: value, options: map[string][]string{}})
+			current = &hosts[len(hosts)-1]
+			skip = false
+		case "match":
+			skip = true
+		default:
+			if current != nil && !skip {
+				current.options[key] = append(current.options[key], value)
+			}
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Get server config from ssh_config.
+// Same as ssh, the first obtained value in matched Host sections is used.
+func getSshConfigServers(path string) (servers map[string]ReadConfig, err error) {
+	usr, _ := user.Current()
+	path = strings.Replace(path, "~", usr.HomeDir, 1)
+
+	hosts, err := parseSshConfig(path)
+	if err != nil {
+		return
+	}
+
+	servers = map[string]ReadConfig{}
+	for _, host := range hosts {
+		for _, alias := range strings.Fields(host.patterns) {
+			// wildcard and negated pattern is not server
+			if strings.ContainsAny(alias, "*?!") {
+				continue
+			}
+			if _, ok := servers[alias]; ok {
+				continue
+			}
+
+			serverConf := ReadConfig{}
+			for _, h := range hosts {
+				if !matchPatternList(h.patterns, alias) {
+					continue
+				}
+				first := func(key string) string {
+					if values := h.options[key]; len(values) > 0 {
+						return values[0]
+					}
+					return ""
+				}
+				if serverConf.Addr == "" {
+					serverConf.Addr = first("hostname")
+				}
+				if serverConf.User == "" {
+					serverConf.User = first("user")
+				}
+				if serverConf.Port == "" {
+					serverConf.Port = first("port")
+				}
+				if serverConf.ProxyJump == "" {
+					serverConf.ProxyJump = first("proxyjump")
+				}
+				serverConf.Identities = append(serverConf.Identities, h.options["identityfile"]...)
+			}
+
+			if serverConf.Addr == "" {
+				serverConf.Addr = alias
+			}
+			if serverConf.User == "" {
+				serverConf.User = usr.Username
+			}
+			if strings.ToLower(serverConf.ProxyJump) == "none" {
+				serverConf.ProxyJump = ""
+			}
+			servers[alias] = serverConf
+		}
+	}
+	return
+}
+
+// Merge ssh_config hosts to server list (lssh config value is prior)
+func mergeSshConfig(checkConf *Config) error {
+	servers, err := getSshConfigServers(checkConf.Include.SshConfig.Path)
+	if err != nil {
+		return err
+	}
+
+	if checkConf.Server == nil {
+		checkConf.Server = map[string]ReadConfig{}
+	}
+	for name, sshServerConf := range servers {
+		serverConf, exist := checkConf.Server[name]
+		if !exist {
+			serverConf.Note = "(ssh_config)"
+		}
+		serverConf = mergeConfig(serverConf, sshServerConf)
+
+		// ssh default identity files
+		if serverConf.Pass == "" && serverConf.Key == "" && len(serverConf.Identities) == 0 && len(checkConf.Identities) == 0 {
+			serverConf.Identities = GetDefaultIdentities()
+		}
+		checkConf.Server[name] = serverConf
+	}
+	return nil
+}
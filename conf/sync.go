@@ -0,0 +1,304 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Team shared config fragment (server definitions)
+//
+//	[sync.team]
+//	url = "git@github.com:example/lssh-team.git"
+//	path = "servers.toml"
+//	verify = true
+type SyncConfig struct {
+	// https url of config file, or git repository url (".git" suffix, or "git+" prefix)
+	Url string `toml:"url"`
+
+	// config file path in git repository (default is "lssh.conf")
+	Path string `toml:"path"`
+	Ref  string `toml:"ref"`
+
+	// verify signature (https: gpg detached signature "<url>.asc", git: signed commit).
+	// required, synced servers are config from other people.
+	Verify       bool   `toml:"verify"`
+	SignatureUrl string `toml:"signature_url"`
+	Keyring      string `toml:"keyring"`
+
+	// server name prefix (default is "<name>:")
+	Prefix string `toml:"prefix"`
+}
+
+// Check sync source can be trusted (signature is verified)
+func (s SyncConfig) checkVerify() error {
+	if !s.Verify {
+		return fmt.Errorf("'verify' is false (sync without signature verify is not allowed)")
+	}
+	return nil
+}
+
+// Remove fields that run local command or send local files from synced server config
+// (password_cmd, passphrase_cmd, cert_command, ssh_args, rcfiles, env)
+func stripSyncServer(serverConf ReadConfig) ReadConfig {
+	serverConf.PasswordCmd = ""
+	serverConf.PassphraseCmd = ""
+	serverConf.CertCommand = ""
+	serverConf.SshArgs = nil
+	serverConf.RcFiles = nil
+	serverConf.Env = nil
+	return serverConf
+}
+
+// Check url is git repository
+func (s SyncConfig) isGit() bool {
+	return strings.HasPrefix(s.Url, "git+") || strings.HasSuffix(s.Url, ".git")
+}
+
+// Get prefix of sync servers
+func (s SyncConfig) getPrefix(name string) string {
+	if s.Prefix != "" {
+		return s.Prefix
+	}
+	return name + ":"
+}
+
+// Get synced fragment file path (<state dir>/sync/<name><ext>)
+func getSyncFile(name string, syncConf SyncConfig) (string, error) {
+	dir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "sync")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	source := syncConf.Path
+	if !syncConf.isGit() {
+		if u, err := url.Parse(syncConf.Url); err == nil {
+			source = u.Path
+		}
+	}
+	ext := path.Ext(source)
+	if ext == "" {
+		ext = ".toml"
+	}
+	return filepath.Join(dir, name+ext), nil
+}
+
+// Read synced servers (with prefix)
+func readSyncServers(name string, syncConf SyncConfig) (servers map[string]ReadConfig, err error) {
+	syncFile, err := getSyncFile(name, syncConf)
+	if err != nil {
+		return
+	}
+	if _, err = os.Stat(syncFile); os.IsNotExist(err) {
+		// not synced yet
+		return map[string]ReadConfig{}, nil
+	}
+
+	var file includeFile
+	if err = decodeConfigFile(syncFile, &file); err != nil {
+		return
+	}
+
+	servers = map[string]ReadConfig{}
+	for serverName, serverConf := range file.Server {
+		servers[syncConf.getPrefix(name)+serverName] = stripSyncServer(serverConf)
+	}
+	return
+}
+
+// Merge synced servers into config (lssh config value is prior)
+func mergeSync(checkConf *Config) error {
+	names := []string{}
+	for name := range checkConf.Sync {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := checkConf.Sync[name].checkVerify(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		servers, err := readSyncServers(name, checkConf.Sync[name])
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		for serverName, serverConf := range servers {
+			if _, ok := checkConf.Server[serverName]; !ok {
+				checkConf.Server[serverName] = serverConf
+			}
+		}
+	}
+	return nil
+}
+
+// Pull shared config fragment, verify signature, and save it. Return added and removed server names.
+func Sync(name string, syncConf SyncConfig) (added []string, removed []string, err error) {
+	if err = syncConf.checkVerify(); err != nil {
+		return
+	}
+
+	before, err := readSyncServers(name, syncConf)
+	if err != nil {
+		before = map[string]ReadConfig{}
+	}
+
+	var data []byte
+	if syncConf.isGit() {
+		data, err = fetchSyncGit(name, syncConf)
+	} else {
+		data, err = fetchSyncHttps(syncConf)
+	}
+	if err != nil {
+		return
+	}
+
+	// check fragment can be read, before save
+	syncFile, err := getSyncFile(name, syncConf)
+	if err != nil {
+		return
+	}
+	tmpFile := syncFile + ".new" + filepath.Ext(syncFile)
+	if err = ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return
+	}
+	defer os.Remove(tmpFile)
+	var file includeFile
+	if err = decodeConfigFile(tmpFile, &file); err != nil {
+		return
+	}
+	if err = os.Rename(tmpFile, syncFile); err != nil {
+		return
+	}
+
+	after, err := readSyncServers(name, syncConf)
+	if err != nil {
+		return
+	}
+	for serverName := range after {
+		if _, ok := before[serverName]; !ok {
+			added = append(added, serverName)
+		}
+	}
+	for serverName := range before {
+		if _, ok := after[serverName]; !ok {
+			removed = append(removed, serverName)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return
+}
+
+// Fetch config file from https url (and verify gpg signature)
+func fetchSyncHttps(syncConf SyncConfig) (data []byte, err error) {
+	if !strings.HasPrefix(syncConf.Url, "https://") {
+		return nil, fmt.Errorf("url %s is not https", syncConf.Url)
+	}
+	if data, err = httpGet(syncConf.Url); err != nil {
+		return
+	}
+
+	signatureUrl := syncConf.SignatureUrl
+	if signatureUrl == "" {
+		signatureUrl = syncConf.Url + ".asc"
+	}
+	signature, err := httpGet(signatureUrl)
+	if err != nil {
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "lssh-sync")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+	dataFile, signatureFile := filepath.Join(dir, "data"), filepath.Join(dir, "data.asc")
+	ioutil.WriteFile(dataFile, data, 0600)
+	ioutil.WriteFile(signatureFile, signature, 0600)
+
+	args := []string{"--batch", "--verify"}
+	if syncConf.Keyring != "" {
+		args = append([]string{"--no-default-keyring", "--keyring", expandHome(syncConf.Keyring)}, args...)
+	}
+	args = append(args, signatureFile, dataFile)
+	if err = runSyncCommand("gpg", args...); err != nil {
+		return nil, fmt.Errorf("signature verify failed: %v", err)
+	}
+	return
+}
+
+func httpGet(u string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Fetch config file from git repository (and verify commit signature)
+func fetchSyncGit(name string, syncConf SyncConfig) (data []byte, err error) {
+	dir, err := GetStateDir()
+	if err != nil {
+		return
+	}
+	repoDir := filepath.Join(dir, "sync", name+".git")
+	repoUrl := strings.TrimPrefix(syncConf.Url, "git+")
+	ref := syncConf.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if _, statErr := os.Stat(repoDir); os.IsNotExist(statErr) {
+		if err = runSyncCommand("git", "init", "--quiet", "--bare", repoDir); err != nil {
+			return
+		}
+	}
+	if err = runSyncCommand("git", "-C", repoDir, "fetch", "--quiet", "--depth", "1", repoUrl, ref); err != nil {
+		return
+	}
+	commit := "FETCH_HEAD"
+
+	if err = runSyncCommand("git", "-C", repoDir, "verify-commit", commit); err != nil {
+		return nil, fmt.Errorf("signature verify failed: %v", err)
+	}
+
+	filePath := syncConf.Path
+	if filePath == "" {
+		filePath = "lssh.conf"
+	}
+	cmd := exec.Command("git", "-C", repoDir, "show", commit+":"+filePath)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if data, err = cmd.Output(); err != nil {
+		return nil, fmt.Errorf("git show: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return
+}
+
+func runSyncCommand(name string, args ...string) error {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package conf
+
+// Session template (server + remote command + log setting), shown at server list
+type TemplateConfig struct {
+	Server   string `toml:"server"`
+	Command  string `toml:"command"`
+	Terminal *bool  `toml:"terminal"`
+	Log      *bool  `toml:"log"`
+	Note     string `toml:"note"`
+}
+
+// Check session template config
+func checkTemplate(checkConf Config) (errs []ConfigError) {
+	for k, v := range checkConf.Template {
+		if _, ok := checkConf.Server[k]; ok {
+			errs = append(errs, newConfigError("", "template %s: same name server exists.", k))
+		}
+		if _, ok := checkConf.Server[v.Server]; !ok {
+			errs = append(errs, newConfigError("", "template %s: server %s not found.", k, v.Server))
+		}
+	}
+	return
+}
+
+func GetTemplateNameList(listConf Config) (nameList []string) {
+	for k := range listConf.Template {
+		nameList = append(nameList, k)
+	}
+	return
+}
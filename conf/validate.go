@@ -0,0 +1,174 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/crypto/ssh"
+)
+
+// Defined file per server name (config file or include file)
+var serverFiles = map[string]string{}
+
+var errorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// Config error (with file and line context, at Validate)
+type ConfigError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Server  string `json:"server,omitempty"`
+	Message string `json:"message"`
+}
+
+func newConfigError(server string, format string, a ...interface{}) ConfigError {
+	return ConfigError{Server: server, Message: strings.TrimSuffix(fmt.Sprintf(format, a...), "\n")}
+}
+
+func (e ConfigError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return e.Message
+}
+
+// Validate config fully (config values, key files, proxy chains, duplicate server names).
+// Return errors with file and line context.
+func Validate(confPath string) (errs []ConfigError) {
+	checkConf, errs, err := readConfig(confPath)
+	if err != nil {
+		e := ConfigError{File: confPath, Message: err.Error()}
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			e.Line = parseErr.Position.Line
+			e.Message = parseErr.Message
+		} else if m := errorLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+		}
+		return []ConfigError{e}
+	}
+
+	names := GetNameList(checkConf)
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, checkKeyFiles(name, checkConf.Server[name])...)
+		errs = append(errs, checkProxyJump(name, checkConf)...)
+	}
+
+	for i := range errs {
+		if errs[i].Server == "" {
+			continue
+		}
+		if _, ok := checkConf.Server[errs[i].Server]; !ok {
+			continue
+		}
+		file, ok := serverFiles[errs[i].Server]
+		if !ok {
+			// server from ssh_config, sync or inventory
+			continue
+		}
+		errs[i].File = file
+		errs[i].Line = getServerLine(file, errs[i].Server)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		return errs[i].Line < errs[j].Line
+	})
+	return
+}
+
+// Check key files exist, and are private key
+func checkKeyFiles(name string, serverConf ReadConfig) (errs []ConfigError) {
+	keys := append([]string{serverConf.Key}, serverConf.Identities...)
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(expandHome(key))
+		if err != nil {
+			errs = append(errs, newConfigError(name, "%s: key file %v", name, err))
+			continue
+		}
+		if _, err := ssh.ParseRawPrivateKey(data); err != nil {
+			if _, ok := err.(*ssh.PassphraseMissingError); !ok {
+				errs = append(errs, newConfigError(name, "%s: key file %s is not private key (%v)", name, key, err))
+			}
+		}
+	}
+	return
+}
+
+// Check proxy_jump hosts are reachable definition.
+// Jump host is server name in config, or [user@]host[:port] that can resolve.
+func checkProxyJump(name string, checkConf Config) (errs []ConfigError) {
+	proxyJump := checkConf.Server[name].ProxyJump
+	if proxyJump == "" {
+		return
+	}
+
+	for _, jumpHost := range strings.Split(proxyJump, ",") {
+		jumpHost = strings.TrimSpace(jumpHost)
+		if jumpHost == name {
+			errs = append(errs, newConfigError(name, "%s: 'proxy_jump' uses itself", name))
+			continue
+		}
+
+		if jumpConf, ok := checkConf.Server[jumpHost]; ok {
+			// proxy_jump of jump host is not used
+			if jumpConf.ProxyJump != "" {
+				errs = append(errs, newConfigError(name, "%s: 'proxy_jump' %s has proxy_jump %s (add it to chain: \"%s,%s\")", name, jumpHost, jumpConf.ProxyJump, jumpConf.ProxyJump, proxyJump))
+			}
+			continue
+		}
+
+		host := jumpHost
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		host, _, err := SplitHostPort(host)
+		if err == nil && host == "" {
+			err = fmt.Errorf("host is empty")
+		}
+		if err != nil {
+			errs = append(errs, newConfigError(name, "%s: 'proxy_jump' %s %v", name, jumpHost, err))
+			continue
+		}
+		if _, err := net.LookupHost(TrimAddrBrackets(host)); err != nil {
+			errs = append(errs, newConfigError(name, "%s: 'proxy_jump' %s is not server in config, and cannot resolve", name, jumpHost))
+		}
+	}
+	return
+}
+
+// Get line number of server definition in config file (0 is not found)
+func getServerLine(path string, name string) int {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	quoted := regexp.QuoteMeta(name)
+	var re *regexp.Regexp
+	if IsYamlFile(path) || IsJsonFile(path) {
+		re = regexp.MustCompile(`^\s+["']?` + quoted + `["']?\s*:`)
+	} else {
+		re = regexp.MustCompile(`^\s*\[\s*server\.(` + quoted + `|"` + quoted + `"|'` + quoted + `')\s*\]`)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		if re.MatchString(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
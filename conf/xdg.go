@@ -0,0 +1,151 @@
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Directory override (set from config [dirs])
+var dirOverride DirsConfig
+
+// Get XDG base directory ($env or ~/default)/lssh
+func getXdgDir(env string, defaultPath string) string {
+	base := os.Getenv(env)
+	if !filepath.IsAbs(base) {
+		usr, _ := user.Current()
+		base = filepath.Join(usr.HomeDir, defaultPath)
+	}
+	return filepath.Join(base, "lssh")
+}
+
+// Replace "~" to home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		usr, _ := user.Current()
+		path = strings.Replace(path, "~", usr.HomeDir, 1)
+	}
+	return path
+}
+
+// Get directory under base dir (add profile sub directory), and create it
+func getDir(base string) (dir string, err error) {
+	dir = base
+	if currentProfile != "" {
+		if !profileNameRegexp.MatchString(currentProfile) {
+			err = fmt.Errorf("profile name %s is not valid", currentProfile)
+			return
+		}
+		dir = filepath.Join(dir, "profiles", currentProfile)
+	}
+	err = os.MkdirAll(dir, 0700)
+	return
+}
+
+// Get config base directory ($XDG_CONFIG_HOME/lssh)
+func getConfigBaseDir() string {
+	return getXdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// Get state directory ($XDG_STATE_HOME/lssh[/profiles/<name>])
+func GetStateDir() (dir string, err error) {
+	base := getXdgDir("XDG_STATE_HOME", ".local/state")
+	if dirOverride.State != "" {
+		base = expandHome(dirOverride.State)
+	}
+	return getDir(base)
+}
+
+// Get cache directory ($XDG_CACHE_HOME/lssh[/profiles/<name>])
+func GetCacheDir() (dir string, err error) {
+	base := getXdgDir("XDG_CACHE_HOME", ".cache")
+	if dirOverride.Cache != "" {
+		base = expandHome(dirOverride.Cache)
+	}
+	return getDir(base)
+}
+
+// Get default log directory (<state dir>/log)
+func getDefaultLogDir() string {
+	dir, err := GetStateDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "log")
+}
+
+// Move legacy ~/.lssh directory to XDG directories (one time).
+//
+//	~/.lssh/banner               => $XDG_STATE_HOME/lssh/banner
+//	~/.lssh/cert                 => $XDG_CACHE_HOME/lssh/cert
+//	~/.lssh/profiles/<name>/...  => $XDG_CONFIG_HOME/lssh/profiles/<name>/... (banner and cert are same as above)
+func MigrateLegacyDirs() {
+	usr, err := user.Current()
+	if err != nil {
+		return
+	}
+	legacyDir := filepath.Join(usr.HomeDir, ".lssh")
+	if _, err := os.Stat(legacyDir); err != nil {
+		return
+	}
+
+	stateBase := getXdgDir("XDG_STATE_HOME", ".local/state")
+	cacheBase := getXdgDir("XDG_CACHE_HOME", ".cache")
+	configBase := getConfigBaseDir()
+
+	migrateDir(filepath.Join(legacyDir, "banner"), filepath.Join(stateBase, "banner"))
+	migrateDir(filepath.Join(legacyDir, "cert"), filepath.Join(cacheBase, "cert"))
+
+	profiles, _ := ioutil.ReadDir(filepath.Join(legacyDir, "profiles"))
+	for _, profile := range profiles {
+		name := profile.Name()
+		legacyProfileDir := filepath.Join(legacyDir, "profiles", name)
+		migrateDir(filepath.Join(legacyProfileDir, "banner"), filepath.Join(stateBase, "profiles", name, "banner"))
+		migrateDir(filepath.Join(legacyProfileDir, "cert"), filepath.Join(cacheBase, "profiles", name, "cert"))
+		migrateDir(legacyProfileDir, filepath.Join(configBase, "profiles", name))
+	}
+
+	// remove empty legacy directory
+	os.Remove(filepath.Join(legacyDir, "profiles"))
+	if err := os.Remove(legacyDir); err == nil {
+		fmt.Fprintf(os.Stderr, "Migrated %s to XDG directories.\n", legacyDir)
+	}
+}
+
+func migrateDir(src string, dst string) {
+	if _, err := os.Stat(src); err != nil {
+		return
+	}
+	if _, err := os.Stat(dst); err == nil {
+		fmt.Fprintf(os.Stderr, "migrate skip: %s already exists\n", dst)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate error: %v\n", err)
+		return
+	}
+	if err := os.Rename(src, dst); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate error: %v\n", err)
+	}
+}
+
+// Escape server name for file name ("/", space, shell special chars and leading "." are "%XX").
+// Server name from inventory can include these chars.
+func EscapeFileName(name string) string {
+	escaped := ""
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			escaped += string(c)
+		case strings.IndexByte("_-@:+,=", c) >= 0, c == '.' && i > 0:
+			escaped += string(c)
+		default:
+			escaped += fmt.Sprintf("%%%02X", c)
+		}
+	}
+	return escaped
+}
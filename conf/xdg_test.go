@@ -0,0 +1,28 @@
+package conf
+
+import "testing"
+
+func TestEscapeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"web1", "web1"},
+		{"user@host:2222", "user@host:2222"},
+		{"web1.example.com", "web1.example.com"},
+		{"aws-prod/web 1", "aws-prod%2Fweb%201"},
+		{"..", "%2E."},
+		{"../../etc/passwd", "%2E.%2F..%2Fetc%2Fpasswd"},
+		{".hidden", "%2Ehidden"},
+		{"a;rm -rf ~", "a%3Brm%20-rf%20%7E"},
+		{"$(id)`id`", "%24%28id%29%60id%60"},
+		{"100%", "100%25"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeFileName(tt.name); got != tt.want {
+			t.Errorf("EscapeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
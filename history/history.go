@@ -0,0 +1,75 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const historyFileName = "history.jsonl"
+
+// Connect history entry
+type Entry struct {
+	Server     string    `json:"server"`
+	Mode       string    `json:"mode"`
+	Command    string    `json:"command,omitempty"`
+	Start      time.Time `json:"start"`
+	Duration   float64   `json:"duration"`
+	ExitStatus int       `json:"exit_status"`
+}
+
+func getHistoryFile() (path string, err error) {
+	dir, err := conf.GetStateDir()
+	if err != nil {
+		return
+	}
+	path = filepath.Join(dir, historyFileName)
+	return
+}
+
+// Append entry to history file (<state dir>/history.jsonl)
+func Append(entry Entry) error {
+	path, err := getHistoryFile()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(entry)
+}
+
+// Read all history entries. Broken line is skipped.
+func Read() (entries []Entry, err error) {
+	path, err := getHistoryFile()
+	if err != nil {
+		return
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	err = scanner.Err()
+	return
+}
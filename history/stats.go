@@ -0,0 +1,86 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type serverStats struct {
+	Server   string
+	Count    int
+	Failed   int
+	Duration time.Duration
+	Last     time.Time
+}
+
+type monthStats struct {
+	Month    string
+	Count    int
+	Duration time.Duration
+}
+
+// Print usage stats from history (most connected servers, session time, per month)
+func PrintStats(w io.Writer, entries []Entry) {
+	servers := map[string]*serverStats{}
+	months := map[string]*monthStats{}
+	for _, entry := range entries {
+		duration := time.Duration(entry.Duration * float64(time.Second))
+
+		s, ok := servers[entry.Server]
+		if !ok {
+			s = &serverStats{Server: entry.Server}
+			servers[entry.Server] = s
+		}
+		s.Count++
+		s.Duration += duration
+		if entry.ExitStatus != 0 {
+			s.Failed++
+		}
+		if entry.Start.After(s.Last) {
+			s.Last = entry.Start
+		}
+
+		month := entry.Start.Local().Format("2006-01")
+		m, ok := months[month]
+		if !ok {
+			m = &monthStats{Month: month}
+			months[month] = m
+		}
+		m.Count++
+		m.Duration += duration
+	}
+
+	// Sort by connect count
+	serverList := []*serverStats{}
+	for _, s := range servers {
+		serverList = append(serverList, s)
+	}
+	sort.Slice(serverList, func(i, j int) bool {
+		if serverList[i].Count != serverList[j].Count {
+			return serverList[i].Count > serverList[j].Count
+		}
+		return serverList[i].Server < serverList[j].Server
+	})
+
+	monthList := []*monthStats{}
+	for _, m := range months {
+		monthList = append(monthList, m)
+	}
+	sort.Slice(monthList, func(i, j int) bool { return monthList[i].Month < monthList[j].Month })
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ServerName\tConnect\tFailed\tSession Time\tLast Connect\t")
+	for _, s := range serverList {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t\n", s.Server, s.Count, s.Failed,
+			s.Duration.Round(time.Second), s.Last.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintln(tw, "\t\t\t\t\t")
+	fmt.Fprintln(tw, "Month\tConnect\t\tSession Time\t\t")
+	for _, m := range monthList {
+		fmt.Fprintf(tw, "%s\t%d\t\t%s\t\t\n", m.Month, m.Count, m.Duration.Round(time.Second))
+	}
+	tw.Flush()
+}
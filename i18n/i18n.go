@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+)
+
+// Message key
+const (
+	ListHeaderName    = "ListHeaderName"
+	ListHeaderConnect = "ListHeaderConnect"
+	ListHeaderNote    = "ListHeaderNote"
+	ListPrompt        = "ListPrompt"
+	ServerNotFound    = "ServerNotFound"
+	ServerNotSelected = "ServerNotSelected"
+	SelectServer      = "SelectServer"
+	ExecCommand       = "ExecCommand"
+	ConnectTimeout    = "ConnectTimeout"
+	ConfAddrNotSet    = "ConfAddrNotSet"
+	ConfUserNotSet    = "ConfUserNotSet"
+	ConfAuthNotSet    = "ConfAuthNotSet"
+)
+
+var messages = map[string]map[string]string{
+	"en": {
+		ListHeaderName:    "ServerName",
+		ListHeaderConnect: "Connect Infomation",
+		ListHeaderNote:    "Note",
+		ListPrompt:        "lssh>>",
+		ServerNotFound:    "Input Server not found from list.",
+		ServerNotSelected: "Server not selected.",
+		SelectServer:      "Select Server :%s\n",
+		ExecCommand:       "Exec command  :%s\n",
+		ConnectTimeout:    "ssh connect timeout.",
+		ConfAddrNotSet:    "%s: 'addr' is not inserted.\n",
+		ConfUserNotSet:    "%s: 'user' is not inserted.\n",
+		ConfAuthNotSet:    "%s: Both Password and KeyPath are entered.Please enter either.\n",
+	},
+	"ja": {
+		ListHeaderName:    "サーバ名",
+		ListHeaderConnect: "接続情報",
+		ListHeaderNote:    "備考",
+		ListPrompt:        "lssh>>",
+		ServerNotFound:    "指定されたサーバがリストに存在しません。",
+		ServerNotSelected: "サーバが選択されていません。",
+		SelectServer:      "接続先サーバ :%s\n",
+		ExecCommand:       "実行コマンド :%s\n",
+		ConnectTimeout:    "ssh接続がタイムアウトしました。",
+		ConfAddrNotSet:    "%s: 'addr' が設定されていません。\n",
+		ConfUserNotSet:    "%s: 'user' が設定されていません。\n",
+		ConfAuthNotSet:    "%s: 'pass' または 'key' のどちらかを設定してください。\n",
+	},
+}
+
+var currentLang = getEnvLang()
+
+// Get language from environment value (LC_ALL, LC_MESSAGES, LANG)
+func getEnvLang() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return normalizeLang(value)
+		}
+	}
+	return "en"
+}
+
+// "ja_JP.UTF-8" => "ja"
+func normalizeLang(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_.-@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := messages[lang]; !ok {
+		return "en"
+	}
+	return lang
+}
+
+// Set UI language. If lang is empty, use environment value.
+func SetLang(lang string) {
+	if lang == "" {
+		currentLang = getEnvLang()
+		return
+	}
+	currentLang = normalizeLang(lang)
+}
+
+// Get message at current language
+func T(key string) string {
+	if message, ok := messages[currentLang][key]; ok {
+		return message
+	}
+	return messages["en"][key]
+}
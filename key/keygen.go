@@ -0,0 +1,31 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Get default key path (~/.ssh/lssh_<type>)
+func GetDefaultKeyPath(keyType string) (path string, err error) {
+	sshDir, err := getSshDir()
+	if err != nil {
+		return
+	}
+	path = filepath.Join(sshDir, "lssh_"+keyType)
+	return
+}
+
+// Generate key pair (use ssh-keygen). Passphrase is asked by ssh-keygen.
+func Generate(keyType string, path string, comment string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s: already exists", path)
+	}
+
+	cmd := exec.Command("ssh-keygen", "-t", keyType, "-f", path, "-C", comment)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
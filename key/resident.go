@@ -0,0 +1,71 @@
+package key
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Get ~/.ssh directory
+func getSshDir() (dir string, err error) {
+	usr, err := user.Current()
+	if err != nil {
+		return
+	}
+	dir = filepath.Join(usr.HomeDir, ".ssh")
+	err = os.MkdirAll(dir, 0700)
+	return
+}
+
+// Download resident keys (ed25519-sk/ecdsa-sk) from FIDO security key to ~/.ssh.
+// Use `ssh-keygen -K`, and return downloaded private key handle paths.
+func DownloadResident() (keys []string, err error) {
+	sshDir, err := getSshDir()
+	if err != nil {
+		return
+	}
+
+	before := listResidentKeys(sshDir)
+
+	cmd := exec.Command("ssh-keygen", "-K")
+	cmd.Dir = sshDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		return
+	}
+
+	for path := range listResidentKeys(sshDir) {
+		if _, ok := before[path]; !ok {
+			keys = append(keys, path)
+		}
+	}
+
+	// ssh-keygen -K overwrite same name key, so return all keys if no new file
+	if len(keys) == 0 {
+		for path := range listResidentKeys(sshDir) {
+			keys = append(keys, path)
+		}
+	}
+	return
+}
+
+// List resident key handle files (id_*_sk_rk*)
+func listResidentKeys(dir string) map[string]bool {
+	keys := map[string]bool{}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return keys
+	}
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasPrefix(name, "id_") && strings.Contains(name, "_sk_rk") && !strings.HasSuffix(name, ".pub") {
+			keys[filepath.Join(dir, name)] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,76 @@
+package keychain
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const service = "lssh"
+
+// Get keychain account name of key passphrase (per server and key path)
+func KeyAccount(server string, keyPath string) string {
+	return server + ":" + keyPath
+}
+
+// Get secret of server from OS keychain (macOS Keychain, or secret-service with secret-tool)
+func Get(server string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", server, "-w")
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "server", server)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain: %s is not found: %s", server, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Store secret of server to OS keychain (overwrite if exist)
+func Set(server string, secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// interactive mode (not pass secret as command args)
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(service), quote(server), quote(secret)))
+	default:
+		cmd = exec.Command("secret-tool", "store", "--label", "lssh: "+server, "service", service, "server", server)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+	return run(cmd)
+}
+
+// Delete secret of server from OS keychain
+func Delete(server string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", server)
+	default:
+		cmd = exec.Command("secret-tool", "clear", "service", service, "server", server)
+	}
+	return run(cmd)
+}
+
+// Quote string for security command interactive mode
+func quote(str string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(str) + "\""
+}
+
+func run(cmd *exec.Cmd) error {
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("keychain: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -9,10 +9,24 @@ import (
 	"text/tabwriter"
 
 	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+	"github.com/blacknon/lssh/snapshot"
 	runewidth "github.com/mattn/go-runewidth"
 	termbox "github.com/nsf/termbox-go"
 )
 
+// Preview line (snapshot summary) per list name
+var previewList = map[string]string{}
+
+// Server tags per list name (for "tag:" filter)
+var tagList = map[string][]string{}
+
+// Server aliases per list name (match in keyword filter)
+var aliasList = map[string][]string{}
+
+// Number key per pinned list name
+var pinList = map[string]string{}
+
 type ListArrayInfo struct {
 	Name    string
 	Connect string
@@ -77,12 +91,12 @@ func draw(serverNameList []string, selectCursor int, searchText string) {
 	leftMargin := 2
 	defaultColor := 255
 	defaultBackColor := 255
-	pronpt := "lssh>>"
+	pronpt := i18n.T(i18n.ListPrompt)
 	termbox.Clear(termbox.Attribute(defaultColor+1), termbox.Attribute(defaultBackColor+1))
 
 	// Get Terminal Size
 	_, height := termbox.Size()
-	lineHeight := height - headLine
+	lineHeight := getLineHeight()
 
 	// Set View List Range
 	viewFirstLine := (selectCursor/lineHeight)*lineHeight + 1
@@ -115,9 +129,21 @@ func draw(serverNameList []string, selectCursor int, searchText string) {
 		// Draw filter line
 		drawLine(leftMargin, listKey+headLine, listValue, cursorColor, cursorBackColor)
 		drawFilterLine(leftMargin, listKey+headLine, listValue, cursorColor, cursorBackColor, keywordColor, searchText)
+
+		// Draw pin number key
+		if fields := strings.Fields(listValue); len(fields) > 0 && pinList[fields[0]] != "" {
+			drawLine(0, listKey+headLine, pinList[fields[0]], 3, defaultBackColor)
+		}
 		listKey += 1
 	}
 
+	// View preview of selected line (bottom line)
+	if len(previewList) > 0 && selectViewCursor >= 0 && selectViewCursor < len(serverViewList) {
+		if fields := strings.Fields(serverViewList[selectViewCursor]); len(fields) > 0 {
+			drawLine(leftMargin, height-1, previewList[fields[0]], 6, defaultBackColor)
+		}
+	}
+
 	// Multi-Byte SetCursor
 	x := 0
 	for _, c := range searchText {
@@ -127,17 +153,50 @@ func draw(serverNameList []string, selectCursor int, searchText string) {
 	termbox.Flush()
 }
 
+// Get list view height (without head line, and preview line)
+func getLineHeight() int {
+	headLine := 2
+	_, height := termbox.Size()
+	lineHeight := height - headLine
+	if len(previewList) > 0 {
+		lineHeight -= 1
+	}
+	return lineHeight
+}
+
 // Create View List Data (use text/tabwriter)
 func getListData(serverNameList []string, serverList conf.Config) (listData []string) {
+	pinList = conf.GetPinKeys(serverList)
+
 	buffer := &bytes.Buffer{}
 	tabWriterBuffer := new(tabwriter.Writer)
 	tabWriterBuffer.Init(buffer, 0, 4, 8, ' ', 0)
-	fmt.Fprintln(tabWriterBuffer, "ServerName \tConnect Infomation \tNote \t")
+	fmt.Fprintln(tabWriterBuffer, i18n.T(i18n.ListHeaderName)+" \t"+i18n.T(i18n.ListHeaderConnect)+" \t"+i18n.T(i18n.ListHeaderNote)+" \t")
 
 	for _, key := range serverNameList {
 		serverName := key
-		connectInfomation := serverList.Server[key].User + "@" + serverList.Server[key].Addr
 		serverNote := serverList.Server[key].Note
+
+		// Session template
+		if template, ok := serverList.Template[key]; ok {
+			key = template.Server
+			serverNote = "(" + template.Note + ")"
+			if template.Note == "" {
+				serverNote = "(" + template.Command + ")"
+			}
+		}
+
+		tagList[serverName] = serverList.Server[key].Tags
+		aliasList[serverName] = serverList.Server[key].Aliases
+
+		connectAddr := serverList.Server[key].Addr
+		if strings.Contains(connectAddr, ":") {
+			connectAddr = "[" + connectAddr + "]"
+		}
+		connectInfomation := serverList.Server[key].User + "@" + connectAddr
+		if serverList.Server[key].Port != conf.DefaultPort {
+			connectInfomation = connectInfomation + ":" + serverList.Server[key].Port
+		}
 		fmt.Fprintln(tabWriterBuffer, serverName+"\t"+connectInfomation+"\t"+serverNote+"\t")
 	}
 	tabWriterBuffer.Flush()
@@ -162,6 +221,16 @@ func deleteRune(text string) (returnText string) {
 	return
 }
 
+// Get pinned list name of number key (only in list data)
+func getPinName(listData []string, key string) string {
+	for _, line := range listData[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 && pinList[fields[0]] == key {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
 func getFilterListData(searchText string, listData []string) (returnListData []string) {
 	// SearchText Bounds Space
 	searchWords := strings.Fields(searchText)
@@ -177,13 +246,30 @@ func getFilterListData(searchText string, listData []string) (returnListData []s
 	}
 
 	for i := 0; i < len(searchWords); i += 1 {
+		// "tag:name" is server tag filter
+		if strings.HasPrefix(strings.ToLower(searchWords[i]), "tag:") {
+			loopListData = []string{}
+			for j := 0; j < len(r); j += 1 {
+				fields := strings.Fields(r[j])
+				if len(fields) > 0 && conf.HasTags(conf.ReadConfig{Tags: tagList[fields[0]]}, []string{searchWords[i][4:]}) {
+					loopListData = append(loopListData, r[j])
+				}
+			}
+			r = loopListData
+			continue
+		}
+
 		searchWordMeta := regexp.QuoteMeta(strings.ToLower(searchWords[i]))
 		re := regexp.MustCompile(searchWordMeta)
 		loopListData = []string{}
 
 		for j := 0; j < len(r); j += 1 {
 			line += string(r[j])
-			if re.MatchString(strings.ToLower(line)) {
+			matchText := line
+			if fields := strings.Fields(line); len(fields) > 0 {
+				matchText += " " + strings.Join(aliasList[fields[0]], " ")
+			}
+			if re.MatchString(strings.ToLower(matchText)) {
 				loopListData = append(loopListData, line)
 			}
 			line = ""
@@ -200,12 +286,37 @@ func pollEvent(serverNameList []string, serverList conf.Config) (lineData string
 	selectline := 0
 	headLine := 2
 
-	_, height := termbox.Size()
-	lineHeight := height - headLine
+	lineHeight := getLineHeight()
 
 	searchText := ""
 
-	filterListData := getFilterListData(searchText, listData)
+	// Hierarchy tree view
+	var hierarchy *regexp.Regexp
+	if serverList.UI.Hierarchy != "" {
+		hierarchy = regexp.MustCompile(serverList.UI.Hierarchy)
+	}
+	collapsed := map[string]bool{}
+	groupLines := map[int]string{}
+	getViewListData := func() []string {
+		if hierarchy == nil {
+			return getFilterListData(searchText, listData)
+		}
+		var treeListData []string
+		treeListData, groupLines = getTreeListData(searchText, listData, hierarchy, collapsed)
+		return treeListData
+	}
+
+	// Toggle group line (collapse/expand). If selectline is not group line, return false.
+	toggleGroup := func() bool {
+		groupPath, ok := groupLines[selectline+1]
+		if !ok {
+			return false
+		}
+		collapsed[groupPath] = !collapsed[groupPath]
+		return true
+	}
+
+	filterListData := getViewListData()
 	draw(filterListData, selectline, searchText)
 	for {
 		switch ev := termbox.PollEvent(); ev.Type {
@@ -247,8 +358,13 @@ func pollEvent(serverNameList []string, serverList conf.Config) (lineData string
 
 				draw(filterListData, selectline, searchText)
 
-			// Enter Key
+			// Enter Key (toggle group at tree view)
 			case termbox.KeyEnter:
+				if toggleGroup() {
+					filterListData = getViewListData()
+					draw(filterListData, selectline, searchText)
+					break
+				}
 				lineData = strings.Fields(filterListData[selectline+1])[0]
 				return
 
@@ -256,7 +372,7 @@ func pollEvent(serverNameList []string, serverList conf.Config) (lineData string
 			case termbox.KeyBackspace, termbox.KeyBackspace2:
 				if len(searchText) > 0 {
 					searchText = deleteRune(searchText)
-					filterListData = getFilterListData(searchText, listData)
+					filterListData = getViewListData()
 					if selectline > len(filterListData) {
 						selectline = len(filterListData)
 					}
@@ -273,15 +389,62 @@ func pollEvent(serverNameList []string, serverList conf.Config) (lineData string
 
 			// Other Key
 			default:
+				// Number key is pinned server (at empty search text)
+				if searchText == "" && ev.Ch >= '0' && ev.Ch <= '9' {
+					if name := getPinName(listData, string(ev.Ch)); name != "" {
+						lineData = name
+						return
+					}
+				}
 				if ev.Ch != 0 {
 					searchText = insertRune(searchText, ev.Ch)
-					filterListData = getFilterListData(searchText, listData)
+					filterListData = getViewListData()
 					if selectline > len(filterListData)-headLine {
 						selectline = len(filterListData) - headLine
 					}
 					draw(filterListData, selectline, searchText)
 				}
 			}
+
+		// Get Mouse Event
+		case termbox.EventMouse:
+			switch ev.Key {
+			// Left Click (click selected line again to connect)
+			case termbox.MouseLeft:
+				clickLine := ev.MouseY - headLine
+				if clickLine < 0 || clickLine >= lineHeight {
+					break
+				}
+				clickSelectLine := (selectline/lineHeight)*lineHeight + clickLine
+				if clickSelectLine > len(filterListData)-headLine {
+					break
+				}
+				if clickSelectLine == selectline {
+					if toggleGroup() {
+						filterListData = getViewListData()
+						draw(filterListData, selectline, searchText)
+						break
+					}
+					lineData = strings.Fields(filterListData[selectline+1])[0]
+					return
+				}
+				selectline = clickSelectLine
+				draw(filterListData, selectline, searchText)
+
+			// Wheel Up
+			case termbox.MouseWheelUp:
+				if selectline > 0 {
+					selectline -= 1
+				}
+				draw(filterListData, selectline, searchText)
+
+			// Wheel Down
+			case termbox.MouseWheelDown:
+				if selectline < len(filterListData)-headLine {
+					selectline += 1
+				}
+				draw(filterListData, selectline, searchText)
+			}
 		default:
 			draw(filterListData, selectline, searchText)
 		}
@@ -294,6 +457,25 @@ func DrawList(serverNameList []string, serverList conf.Config) (lineName string)
 		panic(err)
 	}
 
+	// Enable mouse event
+	if serverList.UI.Mouse {
+		termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+	}
+
+	// Load snapshot preview
+	for _, name := range serverNameList {
+		server := name
+		if template, ok := serverList.Template[name]; ok {
+			server = template.Server
+		}
+		if !serverList.Server[server].Snapshot {
+			continue
+		}
+		if snap, err := snapshot.Load(server); err == nil {
+			previewList[name] = snap.Summary()
+		}
+	}
+
 	lineName = pollEvent(serverNameList, serverList)
 	return lineName
 }
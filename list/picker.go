@@ -0,0 +1,48 @@
+package list
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+// Draw list with external picker command (ex. "fzf", "sk"), and get select server name.
+// List is passed to stdin, and first field of output line is server name.
+func DrawPickerList(picker string, serverNameList []string, serverList conf.Config) (lineName string) {
+	listData := getListData(serverNameList, serverList)
+
+	// fzf and skim: header line is not selectable
+	pickerCmd := picker
+	if fields := strings.Fields(picker); len(fields) > 0 {
+		switch filepath.Base(fields[0]) {
+		case "fzf", "sk":
+			pickerCmd = picker + " --header-lines=1"
+		}
+	}
+
+	stdout := &bytes.Buffer{}
+	cmd := exec.Command("/bin/sh", "-c", pickerCmd)
+	cmd.Stdin = strings.NewReader(strings.Join(listData, ""))
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Fprintf(os.Stderr, "picker %s: %v\n", picker, err)
+			os.Exit(1)
+		}
+		// canceled
+		return i18n.T(i18n.ListHeaderName)
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return i18n.T(i18n.ListHeaderName)
+	}
+	return fields[0]
+}
@@ -0,0 +1,61 @@
+package list
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+// Draw numbered plain list (no cursor addressing), and get select server name.
+// Input number to select, or keyword to filter list.
+func DrawPlainList(serverNameList []string, serverList conf.Config) (lineName string) {
+	listData := getListData(serverNameList, serverList)
+	filterListData := listData
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		// View List
+		fmt.Fprintf(os.Stderr, "    %s", filterListData[0])
+		for i, line := range filterListData[1:] {
+			fmt.Fprintf(os.Stderr, "%3d %s", i+1, line)
+		}
+
+		fmt.Fprint(os.Stderr, i18n.T(i18n.ListPrompt)+" ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			os.Exit(1)
+		}
+		input = strings.TrimSpace(input)
+
+		// Select number
+		if num, err := strconv.Atoi(input); err == nil {
+			if num >= 1 && num < len(filterListData) {
+				lineName = strings.Fields(filterListData[num])[0]
+				return
+			}
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
+			continue
+		}
+
+		// Select pinned server (@3)
+		if strings.HasPrefix(input, "@") {
+			if name := getPinName(listData, input[1:]); name != "" {
+				lineName = name
+				return
+			}
+		}
+
+		// Filter
+		filterListData = getFilterListData(input, listData)
+		if len(filterListData) == 1 {
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
+			filterListData = listData
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package list
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Server line with hierarchy groups (ex. env/region/role)
+type treeItem struct {
+	groups []string
+	line   string
+}
+
+// Get hierarchy groups from server name (regexp submatch), and rest of name after match
+func getGroups(hierarchy *regexp.Regexp, name string) (groups []string, rest string) {
+	rest = name
+	match := hierarchy.FindStringSubmatch(name)
+	if match == nil {
+		return
+	}
+	for _, group := range match[1:] {
+		if group != "" {
+			groups = append(groups, group)
+		}
+	}
+	if len(name) > len(match[0]) && strings.HasPrefix(name, match[0]) {
+		rest = name[len(match[0]):]
+	}
+	return
+}
+
+// Match path style pattern ("prod/eu/web*") to groups and server name (or rest of name)
+func matchPath(pattern string, groups []string, name string, rest string) bool {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(segments) > len(groups)+1 {
+		return false
+	}
+	for i, segment := range segments {
+		if i < len(groups) {
+			if ok, err := path.Match(segment, groups[i]); err != nil || !ok {
+				return false
+			}
+			continue
+		}
+		okName, _ := path.Match(segment, name)
+		okRest, _ := path.Match(segment, rest)
+		if !okName && !okRest {
+			return false
+		}
+	}
+	return true
+}
+
+// Create tree view list data (group line is "▾ group/", collapsed group is "▸ group/").
+// Search word include "/" is path style filter, other words are keyword filter.
+// Return list data, and group path of group line index.
+func getTreeListData(searchText string, listData []string, hierarchy *regexp.Regexp, collapsed map[string]bool) (treeListData []string, groupLines map[int]string) {
+	groupLines = map[int]string{}
+	treeListData = append(treeListData, listData[0])
+
+	pathPatterns := []string{}
+	keywords := []string{}
+	for _, word := range strings.Fields(searchText) {
+		if strings.Contains(word, "/") {
+			pathPatterns = append(pathPatterns, word)
+		} else {
+			keywords = append(keywords, word)
+		}
+	}
+
+	items := []treeItem{}
+	for _, line := range getFilterListData(strings.Join(keywords, " "), listData)[1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		groups, rest := getGroups(hierarchy, fields[0])
+
+		match := true
+		for _, pattern := range pathPatterns {
+			if !matchPath(pattern, groups, fields[0], rest) {
+				match = false
+			}
+		}
+		if match {
+			items = append(items, treeItem{groups: groups, line: line})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return strings.Join(items[i].groups, "/") < strings.Join(items[j].groups, "/")
+	})
+
+	prevGroups := []string{}
+	for _, item := range items {
+		hidden := false
+		for depth := range item.groups {
+			groupPath := strings.Join(item.groups[:depth+1], "/")
+			if depth >= len(prevGroups) || strings.Join(prevGroups[:depth+1], "/") != groupPath {
+				mark := "▾"
+				if collapsed[groupPath] {
+					mark = "▸"
+				}
+				groupLines[len(treeListData)] = groupPath
+				treeListData = append(treeListData, strings.Repeat("  ", depth)+mark+" "+item.groups[depth]+"/\n")
+			}
+			if collapsed[groupPath] {
+				hidden = true
+				break
+			}
+		}
+		prevGroups = item.groups
+
+		if !hidden {
+			treeListData = append(treeListData, strings.Repeat("  ", len(item.groups))+item.line)
+		}
+	}
+	return
+}
@@ -3,28 +3,44 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/user"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
 
 	arg "github.com/alexflint/go-arg"
 	"github.com/blacknon/lssh/check"
+	"github.com/blacknon/lssh/compat"
 	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/history"
+	"github.com/blacknon/lssh/i18n"
 	"github.com/blacknon/lssh/list"
+	"github.com/blacknon/lssh/notify"
 	"github.com/blacknon/lssh/ssh"
 )
 
 // Command Option
 type CommandOption struct {
-	Host     string   `arg:"-H,help:Connect servername"`
-	File     string   `arg:"-f,help:config file path"`
-	Terminal bool     `arg:"-T,help:Run specified command at terminal"`
-	Command  []string `arg:"positional,help:Remote Server exec command."`
+	Host        string   `arg:"-H,help:Connect servername"`
+	File        string   `arg:"-f,help:config file path"`
+	Terminal    bool     `arg:"-T,help:Run specified command at terminal"`
+	Notify      bool     `arg:"help:Desktop notification when finished"`
+	NotifyAfter string   `arg:"--notify-after,help:notify only when run longer than this (ex. 30s) [default: 10s]"`
+	View        bool     `arg:"help:Read-only view session (local input is blocked)"`
+	PlainUI     bool     `arg:"--plain-ui,help:Use numbered plain list instead of full screen list"`
+	Picker      string   `arg:"--picker,help:Use external picker command for server list (ex. fzf)"`
+	Profile     string   `arg:"help:workspace profile name [default: $LSSH_PROFILE]"`
+	Set         []string `arg:"--set,separate,help:override server config for this run (key=value)"`
+	Refresh     bool     `arg:"--refresh-inventory,help:refresh dynamic inventory cache"`
+	Tag         []string `arg:"--tag,separate,help:select servers with tag"`
+	Command     []string `arg:"positional,help:Remote Server exec command."`
 }
 
+const version = "v0.2"
+
 // Version Setting
 func (CommandOption) Version() string {
-	return "lssh v0.2"
+	return "lssh " + version
 }
 
 func main() {
@@ -32,9 +48,33 @@ func main() {
 	check.OsCheck()
 	check.DefCommandExistCheck()
 
-	// Set default value
-	usr, _ := user.Current()
-	defaultConfPath := usr.HomeDir + "/.lssh.conf"
+	// Move legacy ~/.lssh to XDG directories
+	conf.MigrateLegacyDirs()
+
+	// Set default value (use $LSSH_PROFILE, or leading --profile for sub command)
+	profile, osArgs := getLeadingProfile(os.Args[1:])
+	os.Args = append([]string{os.Args[0]}, osArgs...)
+	if profile == "" {
+		profile = conf.GetProfile()
+	}
+	if err := conf.SetProfile(profile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defaultConfPath := conf.GetDefaultConfPath()
+
+	// Exec sub command (lssh wol ...)
+	execSubCommand(defaultConfPath)
+
+	// plain ssh mode for other tools (GIT_SSH_COMMAND="lssh --stdio-subsystem", rsync -e "lssh --stdio-subsystem")
+	if len(os.Args) > 1 && os.Args[1] == "--stdio-subsystem" {
+		os.Exit(stdioCommand(defaultConfPath, os.Args[2:]))
+	}
+
+	// ssh compatible command line (lssh [ssh options] user@host [command])
+	if compat.IsCompatArgs(os.Args[1:]) {
+		os.Exit(compatCommand(defaultConfPath, os.Args[1:]))
+	}
 
 	// get Command Option
 	var args struct {
@@ -47,32 +87,151 @@ func main() {
 
 	// set option value
 	configFile := args.File
+	if args.Profile != "" {
+		if err := conf.SetProfile(args.Profile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if configFile == defaultConfPath {
+			configFile = conf.GetDefaultConfPath()
+		}
+	}
 	execRemoteCmd := args.Command
 	terminalExec := args.Terminal
 	connectHost := args.Host
+	notifyEnable := args.Notify
+	notifyAfter := 10 * time.Second
+	if args.NotifyAfter != "" {
+		d, err := time.ParseDuration(args.NotifyAfter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		notifyAfter = d
+	}
+	plainUI := args.PlainUI
 
 	// Get List
+	conf.SetRefreshInventory(args.Refresh)
 	listConf := conf.ConfigCheckRead(configFile)
 
 	// Get Server Name List (and sort List)
 	nameList := conf.GetNameList(listConf)
+	nameList = append(nameList, conf.GetTemplateNameList(listConf)...)
 	sort.Strings(nameList)
 
+	// Select servers by tag (--tag prod --tag db)
+	if len(args.Tag) > 0 {
+		tagNameList := []string{}
+		for _, name := range nameList {
+			server := name
+			if template, ok := listConf.Template[name]; ok {
+				server = template.Server
+			}
+			if conf.HasTags(listConf.Server[server], args.Tag) {
+				tagNameList = append(tagNameList, name)
+			}
+		}
+		if len(tagNameList) == 0 {
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
+			os.Exit(1)
+		}
+		nameList = tagNameList
+		if len(nameList) == 1 && connectHost == "" {
+			connectHost = nameList[0]
+		}
+	}
+
+	// Pinned server (lssh @3 [command])
+	if connectHost == "" && len(execRemoteCmd) > 0 {
+		if name, ok := conf.GetPinName(listConf, execRemoteCmd[0]); ok {
+			connectHost = name
+			execRemoteCmd = execRemoteCmd[1:]
+		} else if len(execRemoteCmd[0]) == 2 && execRemoteCmd[0][0] == '@' && unicode.IsDigit(rune(execRemoteCmd[0][1])) {
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
+			os.Exit(1)
+		}
+	}
+
 	selectServer := ""
 	if connectHost != "" {
+		// "servername:port" shorthand
+		if check.CheckInputServerExit(connectHost, nameList) == false {
+			if host, port, err := conf.SplitHostPort(connectHost); err == nil && port != "" && check.CheckInputServerExit(host, nameList) {
+				connectHost = host
+				serverConf := listConf.Server[host]
+				serverConf.Port = port
+				listConf.Server[host] = serverConf
+			}
+		}
+
+		// server alias
+		if check.CheckInputServerExit(connectHost, nameList) == false {
+			if server, ok := conf.GetAliasServer(listConf, connectHost); ok && check.CheckInputServerExit(server, nameList) {
+				connectHost = server
+			}
+		}
+
 		if check.CheckInputServerExit(connectHost, nameList) == false {
-			fmt.Fprintln(os.Stderr, "Input Server not found from list.")
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
 			os.Exit(1)
 		} else {
 			selectServer = connectHost
 		}
 	} else {
 		// View List And Get Select Line
-		selectServer = list.DrawList(nameList, listConf)
-		if selectServer == "ServerName" {
-			fmt.Fprintln(os.Stderr, "Server not selected.")
+		picker := args.Picker
+		if picker == "" {
+			picker = listConf.UI.Picker
+		}
+		if picker != "" {
+			selectServer = list.DrawPickerList(picker, nameList, listConf)
+		} else if plainUI || listConf.UI.Plain {
+			selectServer = list.DrawPlainList(nameList, listConf)
+		} else {
+			selectServer = list.DrawList(nameList, listConf)
+		}
+		if selectServer == i18n.T(i18n.ListHeaderName) {
+			fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotSelected))
+			os.Exit(1)
+		}
+	}
+
+	// Session template
+	expandCmd := false
+	if template, ok := listConf.Template[selectServer]; ok {
+		selectServer = template.Server
+		if len(execRemoteCmd) == 0 && template.Command != "" {
+			execRemoteCmd = []string{template.Command}
+			expandCmd = true
+			terminalExec = template.Terminal == nil || *template.Terminal
+		}
+		if template.Log != nil {
+			listConf.Log.Enable = *template.Log
+		}
+	}
+
+	// Override server config (--set key=value)
+	if len(args.Set) > 0 {
+		serverConf := listConf.Server[selectServer]
+		for _, option := range args.Set {
+			var err error
+			if serverConf, err = conf.SetOption(serverConf, option); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		listConf.Server[selectServer] = serverConf
+	}
+
+	// Ask template command parameters ({{prompt "..."}})
+	if expandCmd {
+		command, err := ssh.ExpandCommand(selectServer, listConf, execRemoteCmd[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		execRemoteCmd = []string{command}
 	}
 
 	// Get exec command line.
@@ -85,12 +244,73 @@ func main() {
 	}
 	fmt.Println(cName)
 
+	// Read-only view session
+	if args.View {
+		os.Exit(ssh.ConnectSshView(selectServer, listConf, execRemoteCmd...))
+	}
+
+	os.Exit(connect(selectServer, listConf, execRemoteCmd, terminalExec, notifyEnable, notifyAfter))
+}
+
+// Get leading "--profile name" (or "--profile=name") option, and other args.
+// sub command (lssh --profile work check) is dispatched before option parse.
+func getLeadingProfile(args []string) (profile string, other []string) {
+	other = args
+	for len(other) > 0 {
+		switch {
+		case other[0] == "--profile" && len(other) > 1:
+			profile = other[1]
+			other = other[2:]
+		case strings.HasPrefix(other[0], "--profile="):
+			profile = strings.TrimPrefix(other[0], "--profile=")
+			other = other[1:]
+		default:
+			return
+		}
+	}
+	return
+}
+
+// Connect server (terminal or exec command), and write history
+func connect(selectServer string, listConf conf.Config, execRemoteCmd []string, terminalExec bool, notifyEnable bool, notifyAfter time.Duration) int {
+	// Wake-on-LAN before connect
+	if listConf.Server[selectServer].Wol {
+		if err := ssh.WakeUp(selectServer, listConf); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
 	// Exec Connect ssh
+	startTime := time.Now()
+	exitStatus := 0
+	connectMode := ""
 	if terminalExec == false && len(execRemoteCmd) != 0 {
 		// Connect SSH Terminal
-		os.Exit(ssh.ConnectSshCommand(selectServer, listConf, execRemoteCmd...))
+		connectMode = "command"
+		exitStatus = ssh.ConnectSshCommand(selectServer, listConf, execRemoteCmd...)
 	} else {
 		// Exec SSH Command Only
-		os.Exit(ssh.ConnectSshTerminal(selectServer, listConf, execRemoteCmd...))
+		connectMode = "terminal"
+		exitStatus = ssh.ConnectSshTerminal(selectServer, listConf, execRemoteCmd...)
+	}
+
+	// Write history
+	historyEntry := history.Entry{
+		Server:     selectServer,
+		Mode:       connectMode,
+		Command:    strings.Join(execRemoteCmd, " "),
+		Start:      startTime,
+		Duration:   time.Since(startTime).Seconds(),
+		ExitStatus: exitStatus,
+	}
+	if err := history.Append(historyEntry); err != nil {
+		fmt.Fprintf(os.Stderr, "history write error: %v\n", err)
+	}
+
+	// Desktop notification (only long running)
+	if notifyEnable && time.Since(startTime) >= notifyAfter {
+		notify.SendExitStatus(selectServer, exitStatus, time.Since(startTime))
 	}
+	return exitStatus
 }
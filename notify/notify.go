@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Send desktop notification (notify-send or osascript)
+func Send(title string, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		return fmt.Errorf("desktop notification is not supported on windows")
+	case "darwin":
+		script := "display notification " + strconv.Quote(message) + " with title " + strconv.Quote(title)
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		cmd = exec.Command("notify-send", "-a", "lssh", title, message)
+	}
+	return cmd.Run()
+}
+
+// Send remote command finish notification
+func SendExitStatus(connectServer string, exitStatus int, elapsed time.Duration) {
+	title := "lssh: " + connectServer
+	message := fmt.Sprintf("finished with exit status %d (%s)", exitStatus, elapsed.Round(time.Second))
+	if err := Send(title, message); err != nil {
+		fmt.Fprintf(os.Stderr, "notify error: %s\n", err)
+	}
+}
@@ -0,0 +1,160 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Prompt type
+const (
+	Password   = "password"
+	Passphrase = "passphrase"
+	Otp        = "otp"
+)
+
+// Prompt backend
+const (
+	Auto     = "auto"
+	Tty      = "tty"
+	Askpass  = "askpass"
+	Pinentry = "pinentry"
+	None     = "none"
+)
+
+// Ask user input with backend ("auto", "tty", "askpass", "pinentry").
+// "auto" use tty, and fallback to $SSH_ASKPASS or pinentry when no tty is available.
+func Ask(backend string, message string, echo bool) (string, error) {
+	switch backend {
+	case Tty:
+		return askTty(message, echo)
+	case Askpass:
+		return askAskpass(message, echo)
+	case Pinentry:
+		return askPinentry(message)
+	case None:
+		return "", fmt.Errorf("prompt is disabled")
+	case "", Auto:
+		if answer, err := askTty(message, echo); err == nil {
+			return answer, nil
+		}
+		if os.Getenv("SSH_ASKPASS") != "" {
+			return askAskpass(message, echo)
+		}
+		if _, err := exec.LookPath("pinentry"); err == nil {
+			return askPinentry(message)
+		}
+		return "", fmt.Errorf("no prompt backend is available (tty, SSH_ASKPASS, pinentry)")
+	}
+	return "", fmt.Errorf("prompt backend %s is not supported", backend)
+}
+
+// Ask at /dev/tty (use stty for no echo)
+func askTty(message string, echo bool) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+
+	if !echo {
+		stty := exec.Command("stty", "-echo")
+		stty.Stdin = tty
+		if err := stty.Run(); err != nil {
+			return "", err
+		}
+		defer func() {
+			stty := exec.Command("stty", "echo")
+			stty.Stdin = tty
+			stty.Run()
+			fmt.Fprintln(tty)
+		}()
+	}
+
+	fmt.Fprint(tty, message)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(answer, "\r\n"), nil
+}
+
+// Ask with $SSH_ASKPASS program (like ssh)
+func askAskpass(message string, echo bool) (string, error) {
+	askpass := os.Getenv("SSH_ASKPASS")
+	if askpass == "" {
+		return "", fmt.Errorf("SSH_ASKPASS is not set")
+	}
+
+	cmd := exec.Command(askpass, message)
+	cmd.Stderr = os.Stderr
+	if echo {
+		cmd.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=none")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", askpass, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// Ask with pinentry (Assuan protocol)
+func askPinentry(message string) (answer string, err error) {
+	cmd := exec.Command("pinentry")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	defer cmd.Wait()
+	defer stdin.Close()
+
+	reader := bufio.NewReader(stdout)
+	readResponse := func() (data string, err error) {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "OK"):
+				return data, nil
+			case strings.HasPrefix(line, "ERR"):
+				return "", fmt.Errorf("pinentry: %s", line)
+			case strings.HasPrefix(line, "D "):
+				data, err = url.PathUnescape(line[2:])
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	// greeting
+	if _, err = readResponse(); err != nil {
+		return
+	}
+
+	escaper := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	for _, command := range []string{"SETDESC " + escaper.Replace(message), "SETPROMPT >"} {
+		fmt.Fprintln(stdin, command)
+		if _, err = readResponse(); err != nil {
+			return
+		}
+	}
+
+	fmt.Fprintln(stdin, "GETPIN")
+	answer, err = readResponse()
+	fmt.Fprintln(stdin, "BYE")
+	return
+}
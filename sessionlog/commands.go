@@ -0,0 +1,118 @@
+package sessionlog
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shell integration marker (OSC 133)
+//
+//	A: prompt start, B: command input start, C: command output start, D;<exit status>: command end
+var markerRegexp = regexp.MustCompile("\x1b\\]133;([ABCD])(?:;([^\x07\x1b]*))?(?:\x07|\x1b\\\\)")
+
+// Escape sequence and control charactor (remove from command text)
+var escapeRegexp = regexp.MustCompile("\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)|\x1b\\[[0-9;?]*[A-Za-z]|\x1b[()][A-Za-z0-9]|[\x00-\x08\x0a-\x1f\x7f]")
+
+// Log line time prefix (added by awk strftime("%F %T "))
+const timeFormat = "2006-01-02 15:04:05"
+
+// Command in session log
+type Command struct {
+	Command     string    `json:"command"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	OutputStart int64     `json:"output_start"`
+	OutputEnd   int64     `json:"output_end"`
+	ExitStatus  int       `json:"exit_status"` // -1 is unknown (no or not numeric status)
+}
+
+// Duration of command
+func (c Command) Duration() time.Duration {
+	return c.End.Sub(c.Start)
+}
+
+// Parse commands from session log with shell integration markers
+func ParseCommands(r io.Reader) (commands []Command, err error) {
+	reader := bufio.NewReader(r)
+	offset := int64(0)
+
+	inInput := false
+	inOutput := false
+	input := ""
+	current := Command{}
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line == "" && readErr != nil {
+			if readErr != io.EOF {
+				err = readErr
+			}
+			return
+		}
+		lineOffset := offset
+		offset += int64(len(line))
+
+		// line time, and data
+		lineTime := time.Time{}
+		data := line
+		dataOffset := lineOffset
+		if len(line) > len(timeFormat) {
+			if t, parseErr := time.ParseInLocation(timeFormat, line[:len(timeFormat)], time.Local); parseErr == nil {
+				lineTime = t
+				data = line[len(timeFormat)+1:]
+				dataOffset += int64(len(timeFormat) + 1)
+			}
+		}
+
+		pos := 0
+		for _, m := range markerRegexp.FindAllStringSubmatchIndex(data, -1) {
+			if inInput {
+				input += data[pos:m[0]]
+			}
+			pos = m[1]
+
+			switch data[m[2]:m[3]] {
+			case "A":
+				inInput = false
+				inOutput = false
+			case "B":
+				inInput = true
+				input = ""
+			case "C":
+				inInput = false
+				inOutput = true
+				current = Command{
+					Command:     strings.TrimSpace(escapeRegexp.ReplaceAllString(input, "")),
+					Start:       lineTime,
+					OutputStart: dataOffset + int64(m[1]),
+				}
+			case "D":
+				if inOutput {
+					current.End = lineTime
+					current.OutputEnd = dataOffset + int64(m[0])
+					current.ExitStatus = -1
+					if m[4] >= 0 {
+						if status, atoiErr := strconv.Atoi(data[m[4]:m[5]]); atoiErr == nil {
+							current.ExitStatus = status
+						}
+					}
+					commands = append(commands, current)
+				}
+				inOutput = false
+			}
+		}
+		if inInput {
+			input += data[pos:]
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				err = readErr
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sessionlog
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// Session log line with time prefix (second from 2026-10-14 10:00:00)
+func logLine(sec int, data string) string {
+	t := time.Date(2026, 10, 14, 10, 0, sec, 0, time.Local)
+	return t.Format(timeFormat) + " " + data + "\n"
+}
+
+const (
+	markA = "\x1b]133;A\x07"
+	markB = "\x1b]133;B\x07"
+	markC = "\x1b]133;C\x07"
+)
+
+func markD(status string) string {
+	return "\x1b]133;D;" + status + "\x07"
+}
+
+type wantCommand struct {
+	command    string
+	exitStatus int
+	duration   time.Duration
+}
+
+// ls (0s -> 1s), and exit status 0 (at 3s)
+var lsLog = logLine(0, markA+"user@host:~$ "+markB+"ls\r") +
+	logLine(1, markC+"a.txt\r") +
+	logLine(3, markD("0")+markA+"user@host:~$ "+markB)
+
+func TestParseCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []wantCommand
+	}{
+		{
+			name: "command",
+			log:  lsLog,
+			want: []wantCommand{{"ls", 0, 2 * time.Second}},
+		},
+		{
+			name: "exit status",
+			log: logLine(0, markA+"$ "+markB+"false\r") +
+				logLine(0, markC) +
+				logLine(1, markD("127")+markA+"$ "+markB),
+			want: []wantCommand{{"false", 127, time.Second}},
+		},
+		{
+			name: "st terminator",
+			log: logLine(0, "\x1b]133;A\x1b\\$ \x1b]133;B\x1b\\pwd\r") +
+				logLine(0, "\x1b]133;C\x1b\\/home/user\r") +
+				logLine(0, "\x1b]133;D;2\x1b\\"),
+			want: []wantCommand{{"pwd", 2, 0}},
+		},
+		{
+			name: "input over lines",
+			log: logLine(0, markA+"$ "+markB+"echo a \\\r") +
+				logLine(1, "> b\r") +
+				logLine(1, markC+"a b\r") +
+				logLine(2, markD("0")),
+			want: []wantCommand{{"echo a \\> b", 0, time.Second}},
+		},
+		{
+			name: "no d marker before next prompt",
+			log: logLine(0, markA+"$ "+markB+"sleep 100\r") +
+				logLine(0, markC) +
+				logLine(5, markA+"$ "+markB+"id\r") +
+				logLine(6, markC+"uid=1000\r") +
+				logLine(6, markD("0")),
+			want: []wantCommand{{"id", 0, 0}},
+		},
+		{
+			name: "no d marker at end of log",
+			log: logLine(0, markA+"$ "+markB+"top\r") +
+				logLine(0, markC+"output\r"),
+			want: nil,
+		},
+		{
+			name: "non numeric exit status",
+			log: logLine(0, markA+"$ "+markB+"ls\r") +
+				logLine(0, markC) +
+				logLine(0, markD("abc")),
+			want: []wantCommand{{"ls", -1, 0}},
+		},
+		{
+			name: "no exit status",
+			log: logLine(0, markA+"$ "+markB+"ls\r") +
+				logLine(0, markC) +
+				logLine(0, "\x1b]133;D\x07"),
+			want: []wantCommand{{"ls", -1, 0}},
+		},
+		{
+			name: "no marker",
+			log:  logLine(0, "$ ls\r") + logLine(0, "a.txt\r"),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := ParseCommands(strings.NewReader(tt.log))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkCommands(t, commands, tt.want)
+		})
+	}
+}
+
+// Marker split across reads (log is read by small chunks)
+func TestParseCommandsSplitRead(t *testing.T) {
+	readers := map[string]func(io.Reader) io.Reader{
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+	}
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			commands, err := ParseCommands(newReader(strings.NewReader(lsLog)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkCommands(t, commands, []wantCommand{{"ls", 0, 2 * time.Second}})
+		})
+	}
+}
+
+// Output offset points command output in log file
+func TestParseCommandsOutputOffset(t *testing.T) {
+	commands, err := ParseCommands(strings.NewReader(lsLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 {
+		t.Fatalf("got %d commands, want 1", len(commands))
+	}
+
+	output := lsLog[commands[0].OutputStart:commands[0].OutputEnd]
+	if !strings.HasPrefix(output, "a.txt\r\n") {
+		t.Errorf("output = %q, want prefix %q", output, "a.txt\r\n")
+	}
+	if strings.Contains(output, "\x1b]133;") {
+		t.Errorf("output = %q, include marker", output)
+	}
+}
+
+func TestParseCommandsReadError(t *testing.T) {
+	_, err := ParseCommands(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(lsLog))))
+	if err != iotest.ErrTimeout {
+		t.Errorf("err = %v, want %v", err, iotest.ErrTimeout)
+	}
+}
+
+func checkCommands(t *testing.T, commands []Command, want []wantCommand) {
+	t.Helper()
+	if len(commands) != len(want) {
+		t.Fatalf("got %d commands %+v, want %d", len(commands), commands, len(want))
+	}
+	for i, w := range want {
+		c := commands[i]
+		if c.Command != w.command {
+			t.Errorf("commands[%d].Command = %q, want %q", i, c.Command, w.command)
+		}
+		if c.ExitStatus != w.exitStatus {
+			t.Errorf("commands[%d].ExitStatus = %d, want %d", i, c.ExitStatus, w.exitStatus)
+		}
+		if c.Duration() != w.duration {
+			t.Errorf("commands[%d].Duration() = %v, want %v", i, c.Duration(), w.duration)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sessionlog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Get latest session log file in log dir (file name is "<YYYYmmdd_HHMMSS>_<server>.log").
+// If server is set, latest log of the server.
+func GetLatestFile(logDir string, server string) (path string, err error) {
+	usr, _ := user.Current()
+	logDir = strings.Replace(logDir, "~", usr.HomeDir, 1)
+
+	files, err := ioutil.ReadDir(logDir)
+	if err != nil {
+		return
+	}
+
+	names := []string{}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		// "20060102_150405_" prefix
+		if server != "" && (len(name) < 16 || strings.TrimSuffix(name[16:], ".log") != conf.EscapeFileName(server)) {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		err = fmt.Errorf("session log is not found in %s", logDir)
+		return
+	}
+	sort.Strings(names)
+	path = filepath.Join(logDir, names[len(names)-1])
+	return
+}
@@ -0,0 +1,53 @@
+package sessionlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetLatestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lssh-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"20261014_100000_web1.log",
+		"20261014_110000_web1.log",
+		"20261014_120000_web10.log",
+		"20261014_090000_aws%2Fweb%201.log",
+		"20261014_130000_db1.log",
+		"memo.txt",
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		server  string
+		want    string
+		wantErr bool
+	}{
+		{"", "20261014_130000_db1.log", false},
+		{"web1", "20261014_110000_web1.log", false},
+		{"aws/web 1", "20261014_090000_aws%2Fweb%201.log", false},
+		{"web2", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			path, err := GetLatestFile(dir, tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetLatestFile(%q) error = %v, wantErr %v", tt.server, err, tt.wantErr)
+			}
+			if !tt.wantErr && path != filepath.Join(dir, tt.want) {
+				t.Errorf("GetLatestFile(%q) = %q, want %q", tt.server, path, filepath.Join(dir, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Remote command to get environment snapshot (os name, uptime sec)
+const Command = `(. /etc/os-release 2>/dev/null && echo "$PRETTY_NAME") || uname -sr; cut -d' ' -f1 /proc/uptime 2>/dev/null || echo`
+
+// Remote environment snapshot (saved at each connect)
+type Snapshot struct {
+	OS     string    `json:"os"`
+	Uptime float64   `json:"uptime"`
+	Time   time.Time `json:"time"`
+}
+
+func getSnapshotFile(server string) (path string, err error) {
+	dir, err := conf.GetCacheDir()
+	if err != nil {
+		return
+	}
+	dir = filepath.Join(dir, "snapshot")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	path = filepath.Join(dir, conf.EscapeFileName(server)+".json")
+	return
+}
+
+// Parse snapshot command output
+func Parse(output string) (snap Snapshot) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	snap.Time = time.Now()
+	if len(lines) > 0 {
+		snap.OS = strings.TrimSpace(lines[0])
+	}
+	if len(lines) > 1 {
+		snap.Uptime, _ = strconv.ParseFloat(strings.TrimSpace(lines[1]), 64)
+	}
+	return
+}
+
+// Save snapshot (<cache dir>/snapshot/<server>.json)
+func Save(server string, snap Snapshot) error {
+	path, err := getSnapshotFile(server)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Load last snapshot
+func Load(server string) (snap Snapshot, err error) {
+	path, err := getSnapshotFile(server)
+	if err != nil {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &snap)
+	return
+}
+
+// Summary for server list preview
+// ex) "last seen: Ubuntu 22.04, uptime 41d, you last connected 2024-05-01"
+func (s Snapshot) Summary() string {
+	info := []string{}
+	if s.OS != "" {
+		info = append(info, "last seen: "+s.OS)
+	}
+	if s.Uptime > 0 {
+		// uptime at last connect
+		uptime := time.Duration(s.Uptime) * time.Second
+		if days := int(uptime.Hours() / 24); days > 0 {
+			info = append(info, fmt.Sprintf("uptime %dd", days))
+		} else {
+			info = append(info, fmt.Sprintf("uptime %dh", int(uptime.Hours())))
+		}
+	}
+	info = append(info, "you last connected "+s.Time.Format("2006-01-02"))
+	return strings.Join(info, ", ")
+}
@@ -0,0 +1,251 @@
+package ssh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const msgKexInit = 20
+
+// Weak algorithms (algorithm => severity, reason)
+var weakAlgorithms = map[string][2]string{
+	"diffie-hellman-group1-sha1":         {"error", "1024bit group and SHA-1"},
+	"diffie-hellman-group14-sha1":        {"warn", "SHA-1"},
+	"diffie-hellman-group-exchange-sha1": {"warn", "SHA-1"},
+	"ssh-dss":                            {"error", "DSA 1024bit"},
+	"ssh-rsa":                            {"warn", "SHA-1 signature"},
+	"3des-cbc":                           {"error", "64bit block cipher"},
+	"blowfish-cbc":                       {"error", "64bit block cipher"},
+	"cast128-cbc":                        {"error", "64bit block cipher"},
+	"arcfour":                            {"error", "RC4"},
+	"arcfour128":                         {"error", "RC4"},
+	"arcfour256":                         {"error", "RC4"},
+	"aes128-cbc":                         {"warn", "CBC mode"},
+	"aes192-cbc":                         {"warn", "CBC mode"},
+	"aes256-cbc":                         {"warn", "CBC mode"},
+	"hmac-md5":                           {"error", "MD5"},
+	"hmac-md5-96":                        {"error", "MD5"},
+	"hmac-md5-etm@openssh.com":           {"error", "MD5"},
+	"hmac-md5-96-etm@openssh.com":        {"error", "MD5"},
+	"hmac-sha1":                          {"warn", "SHA-1"},
+	"hmac-sha1-96":                       {"warn", "SHA-1"},
+	"hmac-sha1-etm@openssh.com":          {"warn", "SHA-1"},
+	"umac-64@openssh.com":                {"warn", "64bit tag"},
+	"umac-64-etm@openssh.com":            {"warn", "64bit tag"},
+}
+
+// Audit option
+type AuditOption struct {
+	Parallel int
+	Json     bool
+}
+
+// Audit finding
+type AuditFinding struct {
+	Severity  string `json:"severity"` // warn, error
+	Algorithm string `json:"algorithm"`
+	Message   string `json:"message"`
+}
+
+// Audit result (per server)
+type AuditResult struct {
+	Server            string         `json:"server"`
+	Status            string         `json:"status"` // OK, WARN, ERROR, NG (connect failed)
+	Version           string         `json:"version,omitempty"`
+	KexAlgorithms     []string       `json:"kex_algorithms,omitempty"`
+	HostKeyAlgorithms []string       `json:"host_key_algorithms,omitempty"`
+	Ciphers           []string       `json:"ciphers,omitempty"`
+	Macs              []string       `json:"macs,omitempty"`
+	Findings          []AuditFinding `json:"findings,omitempty"`
+	Message           string         `json:"message,omitempty"`
+}
+
+// Audit servers ssh version and offered algorithms (handshake only, not auth), and print table or json
+func Audit(serverList []string, confList conf.Config, option AuditOption) int {
+	if option.Parallel < 1 {
+		option.Parallel = 1
+	}
+
+	results := make([]AuditResult, len(serverList))
+	sem := make(chan struct{}, option.Parallel)
+	wg := &sync.WaitGroup{}
+	for i, server := range serverList {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, server string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = auditServer(server, getBackgroundConfig(server, confList))
+		}(i, server)
+	}
+	wg.Wait()
+
+	exitStatus := 0
+	for _, r := range results {
+		if r.Status == "NG" || r.Status == "ERROR" {
+			exitStatus = 1
+		}
+	}
+
+	if option.Json {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+		return exitStatus
+	}
+
+	// Print result table
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ServerName\tStatus\tVersion\tFindings\t")
+	for _, r := range results {
+		findings := []string{}
+		for _, f := range r.Findings {
+			findings = append(findings, f.Severity+":"+f.Algorithm)
+		}
+		message := strings.Join(findings, ", ")
+		if r.Message != "" {
+			message = r.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", r.Server, r.Status, r.Version, message)
+	}
+	w.Flush()
+	return exitStatus
+}
+
+func auditServer(connectServer string, confList conf.Config) (result AuditResult) {
+	result.Server = connectServer
+	result.Status = "NG"
+
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+	timeout := 10 * time.Second
+	if serverConf.ConnectTimeout > 0 {
+		timeout = time.Duration(serverConf.ConnectTimeout) * time.Second
+	}
+
+	var conn net.Conn
+	var err error
+	if serverConf.ProxyJump != "" {
+		conn, err = dialProxyJump(connectServer, confList, addr, timeout)
+	} else {
+		conn, err = dialTcp(connectServer, confList, addr, timeout)
+	}
+	if err != nil {
+		result.Message = err.Error()
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	version, kexInit, err := readKexInit(conn)
+	if err != nil {
+		result.Message = err.Error()
+		return
+	}
+	result.Version = version
+	result.KexAlgorithms = kexInit[0]
+	result.HostKeyAlgorithms = kexInit[1]
+	result.Ciphers = mergeNameList(kexInit[2], kexInit[3])
+	result.Macs = mergeNameList(kexInit[4], kexInit[5])
+
+	// Check weak algorithms
+	if !strings.HasPrefix(version, "SSH-2.0-") {
+		result.Findings = append(result.Findings, AuditFinding{Severity: "error", Algorithm: "protocol", Message: "ssh protocol 1 is supported"})
+	}
+	for _, list := range [][]string{result.KexAlgorithms, result.HostKeyAlgorithms, result.Ciphers, result.Macs} {
+		for _, algorithm := range list {
+			if weak, ok := weakAlgorithms[algorithm]; ok {
+				result.Findings = append(result.Findings, AuditFinding{Severity: weak[0], Algorithm: algorithm, Message: weak[1]})
+			}
+		}
+	}
+
+	result.Status = "OK"
+	for _, f := range result.Findings {
+		if f.Severity == "error" {
+			result.Status = "ERROR"
+			break
+		}
+		result.Status = "WARN"
+	}
+	return
+}
+
+// Exchange version, and read server key exchange init (name-lists)
+func readKexInit(conn net.Conn) (version string, nameLists [][]string, err error) {
+	if _, err = io.WriteString(conn, "SSH-2.0-lssh_audit\r\n"); err != nil {
+		return
+	}
+
+	// server version (skip other lines before version)
+	reader := bufio.NewReader(conn)
+	for {
+		var line string
+		if line, err = reader.ReadString('\n'); err != nil {
+			return
+		}
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "SSH-") {
+			version = line
+			break
+		}
+	}
+
+	// binary packet (uint32 packet length, byte padding length, payload, padding)
+	header := make([]byte, 5)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		return
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 256*1024 {
+		err = fmt.Errorf("invalid packet length %d", length)
+		return
+	}
+	packet := make([]byte, length-1)
+	if _, err = io.ReadFull(reader, packet); err != nil {
+		return
+	}
+	payload := packet[:len(packet)-int(header[4])]
+
+	// payload (byte SSH_MSG_KEXINIT, byte[16] cookie, name-list...)
+	if len(payload) < 17 || payload[0] != msgKexInit {
+		err = fmt.Errorf("server kexinit is not received")
+		return
+	}
+	payload = payload[17:]
+	for i := 0; i < 6; i++ {
+		if len(payload) < 4 {
+			err = fmt.Errorf("invalid kexinit packet")
+			return
+		}
+		l := binary.BigEndian.Uint32(payload[:4])
+		if uint32(len(payload)-4) < l {
+			err = fmt.Errorf("invalid kexinit packet")
+			return
+		}
+		nameLists = append(nameLists, strings.Split(string(payload[4:4+l]), ","))
+		payload = payload[4+l:]
+	}
+	return
+}
+
+// Merge name-lists (client to server, server to client) without duplication
+func mergeNameList(a []string, b []string) (list []string) {
+	exists := map[string]bool{}
+	for _, name := range append(a, b...) {
+		if name != "" && !exists[name] {
+			exists[name] = true
+			list = append(list, name)
+		}
+	}
+	return
+}
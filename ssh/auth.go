@@ -0,0 +1,199 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/keychain"
+	"github.com/blacknon/lssh/prompt"
+)
+
+// Get identity file list (key, identities or global default identities)
+func getIdentities(connectServer string, confList conf.Config) (identities []string) {
+	usr, _ := user.Current()
+	list := conf.GetIdentityList(confList.Server[connectServer], confList.Identities)
+	for _, identity := range list {
+		identities = append(identities, strings.Replace(identity, "~", usr.HomeDir, 1))
+	}
+	return
+}
+
+// Get identity file paths of server ("~" is expanded)
+func GetIdentities(connectServer string, confList conf.Config) []string {
+	return getIdentities(connectServer, confList)
+}
+
+// Reporting auth attempt
+type authReporter struct {
+	mu       sync.Mutex
+	offered  string
+	accepted string
+	quiet    bool
+}
+
+// Report offered key is rejected (auth moved to other method, or failed).
+// reason is handshake error, or nil.
+func (r *authReporter) reportRejected(reason error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.offered != "" && r.offered != r.accepted && !r.quiet {
+		if reason != nil {
+			fmt.Fprintf(os.Stderr, "Key rejected  :%s (%s)\n", r.offered, strings.TrimPrefix(reason.Error(), "ssh: handshake failed: "))
+		} else {
+			fmt.Fprintf(os.Stderr, "Key rejected  :%s\n", r.offered)
+		}
+	}
+	r.offered = ""
+}
+
+// Signer which reports offered and accepted key
+type reportSigner struct {
+	ssh.Signer
+	path     string
+	reporter *authReporter
+}
+
+func (s *reportSigner) PublicKey() ssh.PublicKey {
+	s.reporter.mu.Lock()
+	defer s.reporter.mu.Unlock()
+
+	if s.reporter.offered != s.path && !s.reporter.quiet {
+		if s.reporter.offered != "" {
+			fmt.Fprintf(os.Stderr, "Key rejected  :%s\n", s.reporter.offered)
+		}
+		fmt.Fprintf(os.Stderr, "Offering key  :%s (%s)\n", s.path, s.Signer.PublicKey().Type())
+		s.reporter.offered = s.path
+	}
+	return s.Signer.PublicKey()
+}
+
+// Sign is called only when server accepted the offered key
+func (s *reportSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	s.reporter.mu.Lock()
+	s.reporter.accepted = s.path
+	s.reporter.mu.Unlock()
+	return s.Signer.Sign(rand, data)
+}
+
+// Load identity files. Unusable key is reported and skipped.
+func getSigners(connectServer string, confList conf.Config, reporter *authReporter) (signers []ssh.Signer) {
+	for _, identity := range getIdentities(connectServer, confList) {
+		buffer, err := ioutil.ReadFile(identity)
+		if err != nil {
+			if !reporter.quiet {
+				fmt.Fprintf(os.Stderr, "Skip key      :%s (%v)\n", identity, err)
+			}
+			continue
+		}
+
+		signer, err := ssh.ParsePrivateKey(buffer)
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			var passphrase string
+			var fromKeychain bool
+			passphrase, fromKeychain, err = getPassphrase(connectServer, confList, identity)
+			asked := false
+			if err == nil && passphrase == "" {
+				asked = true
+				passphrase, err = prompt.Ask(confList.Prompt.Passphrase, fmt.Sprintf("Enter passphrase for key '%s': ", identity), false)
+			}
+			if err == nil {
+				signer, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(passphrase))
+			}
+
+			// Stored passphrase is wrong (or old), ask again and overwrite it
+			if err != nil && fromKeychain {
+				if !reporter.quiet {
+					fmt.Fprintf(os.Stderr, "Keychain passphrase of key '%s' is not correct.\n", identity)
+				}
+				asked = true
+				passphrase, err = prompt.Ask(confList.Prompt.Passphrase, fmt.Sprintf("Enter passphrase for key '%s': ", identity), false)
+				if err == nil {
+					signer, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(passphrase))
+				}
+			}
+
+			// Store asked passphrase to OS keychain
+			if err == nil && asked && confList.Server[connectServer].Keychain {
+				if storeErr := keychain.Set(keychain.KeyAccount(connectServer, identity), passphrase); storeErr != nil && !reporter.quiet {
+					fmt.Fprintln(os.Stderr, storeErr)
+				}
+			}
+		}
+		if err != nil {
+			if !reporter.quiet {
+				fmt.Fprintf(os.Stderr, "Skip key      :%s (%v)\n", identity, err)
+			}
+			continue
+		}
+		signers = append(signers, &reportSigner{Signer: signer, path: identity, reporter: reporter})
+	}
+	return
+}
+
+// Get password from config (pass or password_cmd), or ask password
+func passwordCallback(connectServer string, confList conf.Config, reporter *authReporter) func() (string, error) {
+	return func() (string, error) {
+		reporter.reportRejected(nil)
+		if password, err := getPassword(connectServer, confList); err != nil || password != "" {
+			return password, err
+		}
+		serverConf := confList.Server[connectServer]
+		message := fmt.Sprintf("%s@%s's password: ", serverConf.User, serverConf.Addr)
+		return prompt.Ask(confList.Prompt.Password, message, false)
+	}
+}
+
+// Answer keyboard-interactive challenge (password in config, and ask OTP)
+func keyboardInteractive(connectServer string, confList conf.Config, reporter *authReporter) ssh.KeyboardInteractiveChallenge {
+	passwordUsed := false
+	return func(user, instruction string, questions []string, echos []bool) (answers []string, err error) {
+		reporter.reportRejected(nil)
+		if instruction != "" {
+			fmt.Fprintln(os.Stderr, instruction)
+		}
+
+		for i, question := range questions {
+			if !passwordUsed && strings.Contains(strings.ToLower(question), "password") {
+				passwordUsed = true
+				connectPass, err := getPassword(connectServer, confList)
+				if err != nil {
+					return nil, err
+				}
+				if connectPass != "" {
+					answers = append(answers, connectPass)
+					continue
+				}
+			}
+
+			answer, err := prompt.Ask(confList.Prompt.Otp, question, echos[i])
+			if err != nil {
+				return nil, err
+			}
+			answers = append(answers, answer)
+		}
+		return
+	}
+}
+
+// Config for background connection while terminal is used (no prompt, no message)
+func getBackgroundConfig(connectServer string, confList conf.Config) conf.Config {
+	backgroundConf := confList
+	backgroundConf.Prompt = conf.PromptConfig{Password: prompt.None, Passphrase: prompt.None, Otp: prompt.None}
+	backgroundConf.Log.Enable = false
+
+	backgroundConf.Server = map[string]conf.ReadConfig{}
+	for k, v := range confList.Server {
+		v.Quiet = true
+		backgroundConf.Server[k] = v
+	}
+	return backgroundConf
+}
@@ -0,0 +1,79 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Create ssh banner callback.
+// Print banner (if not quiet), write banner to log dir, and alert when banner is changed.
+func bannerCallback(connectServer string, confList conf.Config) ssh.BannerCallback {
+	return func(message string) error {
+		changed := checkBannerChanged(connectServer, message)
+
+		if changed {
+			fmt.Fprintf(os.Stderr, "\x1b[1;33m*** %s: ssh banner has changed ***\x1b[0m\n", connectServer)
+		}
+		if !confList.Server[connectServer].Quiet || changed {
+			fmt.Fprint(os.Stderr, message)
+		}
+
+		if confList.Log.Enable {
+			writeBannerLog(connectServer, confList.Log.Dir, message)
+		}
+		return nil
+	}
+}
+
+// Compare banner hash with last time (saved at state directory)
+func checkBannerChanged(connectServer string, message string) bool {
+	stateDir, err := conf.GetStateDir()
+	if err != nil {
+		return false
+	}
+	bannerDir := filepath.Join(stateDir, "banner")
+	if err := os.MkdirAll(bannerDir, 0700); err != nil {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(message))
+	hash := hex.EncodeToString(sum[:])
+
+	hashFile := filepath.Join(bannerDir, conf.EscapeFileName(connectServer))
+	lastHash, err := ioutil.ReadFile(hashFile)
+	ioutil.WriteFile(hashFile, []byte(hash), 0600)
+
+	// first connect
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(lastHash)) != hash
+}
+
+// Append banner to log dir (banner.log)
+func writeBannerLog(connectServer string, logDirPath string, message string) {
+	usr, _ := user.Current()
+	logDirPath = strings.Replace(logDirPath, "~", usr.HomeDir, 1)
+	if err := os.MkdirAll(logDirPath, 0755); err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(logDirPath, "banner.log"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "%s %s\n%s\n", time.Now().Format("2006-01-02 15:04:05"), connectServer, message)
+}
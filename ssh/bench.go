@@ -0,0 +1,168 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+// Benchmark ssh connection (handshake, echo rtt, upload/download throughput)
+func Bench(connectServer string, confList conf.Config, size int64, count int) int {
+	fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+
+	// handshake
+	startTime := time.Now()
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer client.Close()
+	handshakeTime := time.Since(startTime)
+
+	// echo rtt
+	rttMin, rttAvg, rttMax, err := benchEcho(client, count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "echo error: %v\n", err)
+		return 1
+	}
+
+	// upload
+	upTime, err := benchTransfer(client, "cat > /dev/null", size, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upload error: %v\n", err)
+		return 1
+	}
+
+	// download
+	downCmd := "head -c " + strconv.FormatInt(size, 10) + " /dev/zero"
+	downTime, err := benchTransfer(client, downCmd, size, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "download error: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "handshake\t%s\t\n", handshakeTime.Round(time.Microsecond))
+	fmt.Fprintf(w, "echo rtt (min/avg/max)\t%s / %s / %s\t\n",
+		rttMin.Round(time.Microsecond), rttAvg.Round(time.Microsecond), rttMax.Round(time.Microsecond))
+	fmt.Fprintf(w, "upload\t%s\t(%d bytes, %s)\t\n", formatRate(size, upTime), size, upTime.Round(time.Millisecond))
+	fmt.Fprintf(w, "download\t%s\t(%d bytes, %s)\t\n", formatRate(size, downTime), size, downTime.Round(time.Millisecond))
+	w.Flush()
+	return 0
+}
+
+// Measure round trip time through established channel (use remote cat)
+func benchEcho(client *ssh.Client, count int) (rttMin, rttAvg, rttMax time.Duration, err error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err = session.Start("cat"); err != nil {
+		return
+	}
+
+	var total time.Duration
+	buf := make([]byte, 1)
+	for i := 0; i < count; i++ {
+		startTime := time.Now()
+		if _, err = stdin.Write([]byte{'.'}); err != nil {
+			return
+		}
+		if _, err = io.ReadFull(stdout, buf); err != nil {
+			return
+		}
+		rtt := time.Since(startTime)
+
+		total += rtt
+		if rttMin == 0 || rtt < rttMin {
+			rttMin = rtt
+		}
+		if rtt > rttMax {
+			rttMax = rtt
+		}
+	}
+	stdin.Close()
+
+	if count > 0 {
+		rttAvg = total / time.Duration(count)
+	}
+	return
+}
+
+// Measure transfer time (upload: write to remote stdin, download: read remote stdout)
+func benchTransfer(client *ssh.Client, cmd string, size int64, upload bool) (elapsed time.Duration, err error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	zero := io.LimitReader(zeroReader{}, size)
+	startTime := time.Now()
+	if upload {
+		session.Stdin = zero
+		err = session.Run(cmd)
+	} else {
+		stdout, pipeErr := session.StdoutPipe()
+		if pipeErr != nil {
+			return elapsed, pipeErr
+		}
+		if err = session.Start(cmd); err != nil {
+			return
+		}
+		var n int64
+		n, err = io.Copy(ioutil.Discard, stdout)
+		if err == nil && n != size {
+			err = fmt.Errorf("received %d bytes, expected %d bytes", n, size)
+		}
+		if waitErr := session.Wait(); err == nil {
+			err = waitErr
+		}
+	}
+	elapsed = time.Since(startTime)
+	return
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// Format bytes per second
+func formatRate(size int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return "-"
+	}
+	rate := float64(size) / elapsed.Seconds()
+	units := []string{"B/s", "KB/s", "MB/s", "GB/s"}
+	i := 0
+	for rate >= 1024 && i < len(units)-1 {
+		rate /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", rate, units[i])
+}
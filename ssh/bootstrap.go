@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+var rcDirRegexp = regexp.MustCompile(`^/tmp/lssh\.[A-Za-z0-9]+$`)
+
+// Shell integration (bash 4.4 or later). Output OSC 133 command boundary markers.
+const shellIntegrationRc = `__lssh_prompt() { local s=$?; printf '\033]133;D;%s\007\033]133;A\007' "$s"; return $s; }
+PROMPT_COMMAND="__lssh_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+PS1="$PS1\[\033]133;B\007\]"
+PS0='\033]133;C\007'
+`
+
+// Upload rc files (and shell integration) to remote temporary directory, and return directory path.
+// Created "<dir>/rc" sources ~/.bashrc and uploaded files, and removes directory at shell exit.
+func sendRcFiles(connectServer string, confList conf.Config) (rcDir string, err error) {
+	usr, _ := user.Current()
+
+	// Create here document script
+	delimiterBytes := make([]byte, 8)
+	if _, err = rand.Read(delimiterBytes); err != nil {
+		return
+	}
+	delimiter := "LSSH_EOF_" + hex.EncodeToString(delimiterBytes)
+
+	script := &bytes.Buffer{}
+	rc := &bytes.Buffer{}
+	fmt.Fprintln(rc, "[ -f ~/.bashrc ] && . ~/.bashrc")
+	fmt.Fprintln(rc, "trap 'rm -rf \"$LSSH_RC_DIR\"' EXIT")
+	for i, rcFile := range confList.Server[connectServer].RcFiles {
+		rcFile = strings.Replace(rcFile, "~", usr.HomeDir, 1)
+		data, readErr := ioutil.ReadFile(rcFile)
+		if readErr != nil {
+			return "", readErr
+		}
+
+		name := fmt.Sprintf("%02d_%s", i, filepath.Base(rcFile))
+		fmt.Fprintf(script, "cat > '%s' <<'%s'\n%s\n%s\n", name, delimiter, data, delimiter)
+		fmt.Fprintf(rc, ". \"$LSSH_RC_DIR/%s\"\n", name)
+	}
+	if confList.Server[connectServer].ShellIntegration {
+		rc.WriteString(shellIntegrationRc)
+	}
+	fmt.Fprintf(script, "{ echo \"LSSH_RC_DIR=$(pwd)\"; cat; } > rc <<'%s'\n%s%s\n", delimiter, rc.String(), delimiter)
+
+	// Upload
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	session.Stdin = script
+	output, err := session.Output("d=$(mktemp -d /tmp/lssh.XXXXXXXX) && cd \"$d\" && echo \"$d\" && sh")
+	if err != nil {
+		return
+	}
+
+	rcDir = strings.TrimSpace(string(output))
+	if !rcDirRegexp.MatchString(rcDir) {
+		err = fmt.Errorf("rc files upload error: %s", rcDir)
+	}
+	return
+}
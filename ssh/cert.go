@@ -0,0 +1,120 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Renew certificate before it expires
+const certRenewMargin = 60 * time.Second
+
+// Get ssh certificate for server.
+// If cached certificate is expired (or not exist), exec cert_command and save its output.
+func getCertificate(connectServer string, confList conf.Config) (certPath string, cert *ssh.Certificate, err error) {
+	serverConf := confList.Server[connectServer]
+
+	cacheDir, err := conf.GetCacheDir()
+	if err != nil {
+		return
+	}
+	certDir := filepath.Join(cacheDir, "cert")
+	if err = os.MkdirAll(certDir, 0700); err != nil {
+		return
+	}
+	certPath = filepath.Join(certDir, conf.EscapeFileName(connectServer)+"-cert.pub")
+
+	// Use cached certificate
+	if data, readErr := ioutil.ReadFile(certPath); readErr == nil {
+		if cert, err = parseCertificate(data); err == nil && checkCertificateValid(cert) {
+			return
+		}
+	}
+
+	// Exec cert_command
+	// %n: server name, %h: addr, %r: user, %k: public key path
+	pubKeyPath := ""
+	if identities := getIdentities(connectServer, confList); len(identities) > 0 {
+		pubKeyPath = identities[0] + ".pub"
+	}
+	replacer := strings.NewReplacer("%n", connectServer, "%h", serverConf.Addr, "%r", serverConf.User, "%k", pubKeyPath, "%%", "%")
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("/bin/sh", "-c", replacer.Replace(serverConf.CertCommand))
+	cmd.Stderr = stderr
+	cmd.Stdin = os.Stdin
+	data, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("cert_command error: %v: %s", err, strings.TrimSpace(stderr.String()))
+		return
+	}
+
+	cert, err = parseCertificate(data)
+	if err != nil {
+		return
+	}
+	if !checkCertificateValid(cert) {
+		err = fmt.Errorf("cert_command returned expired certificate")
+		return
+	}
+
+	err = ioutil.WriteFile(certPath, data, 0600)
+	return
+}
+
+// Parse authorized_keys format certificate
+func parseCertificate(data []byte) (cert *ssh.Certificate, err error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return
+	}
+
+	cert, ok := pubKey.(*ssh.Certificate)
+	if !ok {
+		err = fmt.Errorf("not ssh certificate")
+	}
+	return
+}
+
+// Check certificate is valid now (with renew margin)
+func checkCertificateValid(cert *ssh.Certificate) bool {
+	now := time.Now()
+	if cert.ValidAfter != 0 && now.Before(time.Unix(int64(cert.ValidAfter), 0)) {
+		return false
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && now.Add(certRenewMargin).After(time.Unix(int64(cert.ValidBefore), 0)) {
+		return false
+	}
+	return true
+}
+
+// Create certificate signer from signers (use the signer that matches certificate key)
+func getCertSigner(cert *ssh.Certificate, certPath string, signers []ssh.Signer) (certSigner ssh.Signer, err error) {
+	certKey := cert.Key.Marshal()
+	for _, signer := range signers {
+		// unwrap report signer (not report at key matching)
+		rs, ok := signer.(*reportSigner)
+		if !ok {
+			continue
+		}
+
+		if bytes.Equal(rs.Signer.PublicKey().Marshal(), certKey) {
+			certSigner, err = ssh.NewCertSigner(cert, rs.Signer)
+			if err != nil {
+				return
+			}
+			certSigner = &reportSigner{Signer: certSigner, path: certPath, reporter: rs.reporter}
+			return
+		}
+	}
+	err = fmt.Errorf("private key for certificate not found")
+	return
+}
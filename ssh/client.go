@@ -0,0 +1,197 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Create ssh client config from server config
+func createSshClientConfig(connectServer string, confList conf.Config) (config *ssh.ClientConfig, err error) {
+	config, _, err = createSshClientConfigWithReporter(connectServer, confList)
+	return
+}
+
+// Create ssh client config, and auth reporter (report rejected key at handshake error)
+func createSshClientConfigWithReporter(connectServer string, confList conf.Config) (config *ssh.ClientConfig, reporter *authReporter, err error) {
+	connectUser := confList.Server[connectServer].User
+
+	// Auth method (try keys in order, and password)
+	auth := []ssh.AuthMethod{}
+	reporter = &authReporter{quiet: confList.Server[connectServer].Quiet}
+	signers := getSigners(connectServer, confList, reporter)
+
+	// ssh certificate
+	if confList.Server[connectServer].CertCommand != "" {
+		certPath, cert, certErr := getCertificate(connectServer, confList)
+		if certErr != nil {
+			return config, reporter, certErr
+		}
+		certSigner, certErr := getCertSigner(cert, certPath, signers)
+		if certErr != nil {
+			return config, reporter, certErr
+		}
+		signers = append([]ssh.Signer{certSigner}, signers...)
+	}
+
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	auth = append(auth, ssh.PasswordCallback(passwordCallback(connectServer, confList, reporter)))
+	auth = append(auth, ssh.KeyboardInteractive(keyboardInteractive(connectServer, confList, reporter)))
+
+	// Host key check
+	hostKeyCallback, err := getHostKeyCallback(connectServer, confList)
+	if err != nil {
+		return
+	}
+
+	// Connect timeout
+	timeout := 60 * time.Second
+	if sec := confList.Server[connectServer].ConnectTimeout; sec > 0 {
+		timeout = time.Duration(sec) * time.Second
+	}
+
+	// Create ssh client config
+	config = &ssh.ClientConfig{
+		User:            connectUser,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+
+	// Crypto algorithms
+	policy, err := conf.GetCryptoPolicy(confList.Server[connectServer])
+	if err != nil {
+		return
+	}
+	config.Ciphers = policy.Ciphers
+	config.MACs = policy.Macs
+	config.KeyExchanges = policy.KexAlgorithms
+	config.HostKeyAlgorithms = policy.HostKeyAlgorithms
+
+	// Rekey data limit (time limit is supported at terminal connect only)
+	if rekeyLimit := confList.Server[connectServer].RekeyLimit; rekeyLimit != "" {
+		data, _, _ := conf.ParseRekeyLimit(rekeyLimit)
+		config.RekeyThreshold = uint64(data)
+	}
+
+	// ssh banner
+	config.BannerCallback = bannerCallback(connectServer, confList)
+	return
+}
+
+// Connect ssh server and return ssh client
+func createSshClient(connectServer string, confList conf.Config) (client *ssh.Client, err error) {
+	connectAddr := confList.Server[connectServer].Addr
+	var connectPort string
+	if confList.Server[connectServer].Port == "" {
+		connectPort = "22"
+	} else {
+		connectPort = confList.Server[connectServer].Port
+	}
+
+	config, reporter, err := createSshClientConfigWithReporter(connectServer, confList)
+	if err != nil {
+		return
+	}
+
+	connectHostPort := net.JoinHostPort(connectAddr, connectPort)
+	var conn net.Conn
+	if confList.Server[connectServer].ProxyJump != "" {
+		conn, err = dialProxyJump(connectServer, confList, connectHostPort, config.Timeout)
+	} else {
+		conn, err = dialTcp(connectServer, confList, connectHostPort, config.Timeout)
+	}
+	if err != nil {
+		err = fmt.Errorf("cannot connect %v: %v", connectHostPort, err)
+		return
+	}
+
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, connectHostPort, config)
+	if err != nil {
+		reporter.reportRejected(err)
+		conn.Close()
+		err = fmt.Errorf("cannot connect %v: %v", connectHostPort, err)
+		return
+	}
+	client = ssh.NewClient(sshConn, channels, requests)
+
+	// keepalive
+	if interval := confList.Server[connectServer].KeepaliveInterval; interval > 0 {
+		go sendKeepalive(client, time.Duration(interval)*time.Second)
+	}
+	return
+}
+
+// Send keepalive request (like OpenSSH ServerAliveInterval) until connection is closed
+func sendKeepalive(client *ssh.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			return
+		}
+	}
+}
+
+// Dial tcp and set socket option from server config
+func dialTcp(connectServer string, confList conf.Config, addr string, timeout time.Duration) (conn net.Conn, err error) {
+	serverConf := confList.Server[connectServer]
+
+	conn, err = net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return
+	}
+
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+
+	// TCP_NODELAY (golang default is true)
+	if serverConf.TcpNoDelay != nil {
+		if err = tcpConn.SetNoDelay(*serverConf.TcpNoDelay); err != nil {
+			conn.Close()
+			return
+		}
+	}
+
+	// SO_SNDBUF / SO_RCVBUF
+	if serverConf.TcpSndBuf > 0 {
+		if err = tcpConn.SetWriteBuffer(serverConf.TcpSndBuf); err != nil {
+			conn.Close()
+			return
+		}
+	}
+	if serverConf.TcpRcvBuf > 0 {
+		if err = tcpConn.SetReadBuffer(serverConf.TcpRcvBuf); err != nil {
+			conn.Close()
+			return
+		}
+	}
+	return
+}
+
+// Connect ssh server and get remote command output
+func getRemoteCommandOutput(connectServer string, confList conf.Config, cmd string) (output string, err error) {
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	buf, err := session.Output(cmd)
+	output = string(buf)
+	return
+}
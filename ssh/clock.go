@@ -0,0 +1,77 @@
+package ssh
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Default clock skew warning threshold(sec) at lssh check
+const defaultClockSkewWarn = 30
+
+// Get clock skew warning threshold(sec)
+func getClockSkewThreshold(connectServer string, confList conf.Config) float64 {
+	if threshold := confList.Server[connectServer].ClockSkewWarn; threshold > 0 {
+		return float64(threshold)
+	}
+	return defaultClockSkewWarn
+}
+
+// Warn when remote clock skew exceeds threshold ("clock_skew_warn" is set only)
+func warnClockSkew(connectServer string, confList conf.Config, client *ssh.Client) {
+	if confList.Server[connectServer].ClockSkewWarn <= 0 {
+		return
+	}
+
+	skew, err := getClockSkew(client)
+	if err != nil {
+		return
+	}
+	if threshold := getClockSkewThreshold(connectServer, confList); math.Abs(skew) > threshold {
+		fmt.Fprintf(os.Stderr, "\x1b[1;33m*** %s: clock skew is %.1fs (Kerberos and TOTP may fail) ***\x1b[0m\n", connectServer, skew)
+	}
+}
+
+// Warn clock skew before terminal connect (use new connection)
+func warnClockSkewTerminal(connectServer string, confList conf.Config) {
+	if confList.Server[connectServer].ClockSkewWarn <= 0 {
+		return
+	}
+
+	client, err := createSshClient(connectServer, getBackgroundConfig(connectServer, confList))
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	warnClockSkew(connectServer, confList, client)
+}
+
+// Get remote clock skew(sec) with "date +%s" (remote - local, compare at midpoint of request)
+func getClockSkew(client *ssh.Client) (skew float64, err error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	startTime := time.Now()
+	output, err := session.Output("date +%s")
+	if err != nil {
+		return
+	}
+	midTime := startTime.Add(time.Since(startTime) / 2)
+
+	remoteTime, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return
+	}
+	skew = float64(remoteTime) - float64(midTime.UnixNano())/float64(time.Second)
+	return
+}
@@ -0,0 +1,37 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Background tint of color name (too bright as background, use dark color)
+var backgroundTints = map[string]string{
+	"red":     "#400000",
+	"green":   "#003000",
+	"blue":    "#000040",
+	"yellow":  "#303000",
+	"magenta": "#300030",
+	"cyan":    "#003030",
+}
+
+// Get terminal background color value (color name, or "#rrggbb")
+func getBackgroundColor(color string) string {
+	if tint, ok := backgroundTints[strings.ToLower(color)]; ok {
+		return tint
+	}
+	return color
+}
+
+// Set terminal background color (OSC 11), and return restore function (OSC 111)
+func setTerminalBackground(color string) (restore func()) {
+	if color == "" {
+		return func() {}
+	}
+
+	fmt.Fprint(os.Stdout, wrapTitleSequence("\x1b]11;"+getBackgroundColor(color)+"\x07"))
+	return func() {
+		fmt.Fprint(os.Stdout, wrapTitleSequence("\x1b]111\x07"))
+	}
+}
@@ -0,0 +1,89 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/prompt"
+)
+
+// Expand remote command template, and ask parameters before exec.
+//
+//	{{prompt "service name"}}                   ask value
+//	{{choose "service name" "nginx" "mysql"}}   select from static list
+//	{{choose_cmd "service name" "remote cmd"}}  select from remote command output lines
+//
+// Input value is shell quoted.
+func ExpandCommand(connectServer string, confList conf.Config, command string) (string, error) {
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+
+	funcMap := template.FuncMap{
+		"prompt": func(message string) (string, error) {
+			answer, err := prompt.Ask(prompt.Auto, message+": ", true)
+			return shellQuote(answer), err
+		},
+		"choose": func(message string, choices ...string) (string, error) {
+			answer, err := askChoice(message, choices)
+			return shellQuote(answer), err
+		},
+		"choose_cmd": func(message string, cmd string) (string, error) {
+			output, err := getRemoteCommandOutput(connectServer, confList, cmd)
+			if err != nil {
+				return "", fmt.Errorf("choose_cmd %s: %v", cmd, err)
+			}
+			choices := []string{}
+			for _, line := range strings.Split(output, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					choices = append(choices, line)
+				}
+			}
+			answer, err := askChoice(message, choices)
+			return shellQuote(answer), err
+		},
+	}
+
+	tmpl, err := template.New("command").Funcs(funcMap).Parse(command)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, nil); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// Print numbered choices and ask (number, or value as is)
+func askChoice(message string, choices []string) (string, error) {
+	for i, choice := range choices {
+		fmt.Fprintf(os.Stderr, "%3d) %s\n", i+1, choice)
+	}
+
+	answer, err := prompt.Ask(prompt.Auto, message+" [1-"+strconv.Itoa(len(choices))+"]: ", true)
+	if err != nil {
+		return "", err
+	}
+	if num, err := strconv.Atoi(answer); err == nil && num >= 1 && num <= len(choices) {
+		return choices[num-1], nil
+	}
+
+	// unique prefix match (completion)
+	matches := []string{}
+	for _, choice := range choices {
+		if strings.HasPrefix(choice, answer) {
+			matches = append(matches, choice)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return answer, nil
+}
@@ -0,0 +1,39 @@
+package ssh
+
+import (
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Add public key to remote ~/.ssh/authorized_keys (skip if already exists)
+func DeployPublicKey(connectServer string, confList conf.Config, pubKey string) error {
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	line := strings.TrimSpace(pubKey)
+	fields := strings.Fields(line)
+	keyBody := line
+	for i, field := range fields {
+		if strings.HasPrefix(field, "ssh-") || strings.HasPrefix(field, "ecdsa-") || strings.HasPrefix(field, "sk-") {
+			if i+1 < len(fields) {
+				keyBody = fields[i+1]
+			}
+			break
+		}
+	}
+
+	deployCmd := "umask 077; mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys && " +
+		"{ grep -qF " + shellQuote(keyBody) + " ~/.ssh/authorized_keys || " +
+		"echo " + shellQuote(line) + " >> ~/.ssh/authorized_keys; }"
+	return session.Run(deployCmd)
+}
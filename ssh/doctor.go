@@ -0,0 +1,218 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+// Suggestion for error message (per step)
+var doctorSuggestions = map[string][][2]string{
+	"dns": {
+		{"no such host", "check 'addr' in config, /etc/hosts or DNS server"},
+		{"", "check DNS server setting (/etc/resolv.conf)"},
+	},
+	"jump": {
+		{"unable to authenticate", "check jump host user/key (jump host use target server auth if not in config)"},
+		{"connect failed", "jump host cannot reach target server: check firewall from jump host"},
+		{"", "check 'proxy_jump' host is reachable (lssh doctor <jump host>)"},
+	},
+	"tcp": {
+		{"connection refused", "sshd is not running, or 'port' is wrong"},
+		{"i/o timeout", "firewall or security group blocks port, or server is down"},
+		{"no route to host", "check network route (vpn, gateway)"},
+		{"", "check network to server"},
+	},
+	"banner": {
+		{"not ssh server", "'port' is not ssh server"},
+		{"", "connection closed by server: check TCP wrapper, MaxStartups or fail2ban ban"},
+	},
+	"kex": {
+		{"no common algorithm", "server ssh algorithm is too old or too new: check sshd version and Ciphers/KexAlgorithms"},
+		{"key mismatch", "host key has changed: check known_hosts (server reinstalled, or man-in-the-middle)"},
+		{"key is unknown", "host key is not in known_hosts: use strict_host_key_checking = \"accept-new\""},
+		{"", "check sshd log at server"},
+	},
+	"auth": {
+		{"unable to authenticate", "check 'user', key (authorized_keys at server) or password. see 'Key rejected' lines"},
+		{"", "check sshd log at server (auth.log, secure)"},
+	},
+	"channel": {
+		{"administratively prohibited", "server rejects session: check MaxSessions or forced command"},
+		{"", "check remote login shell and sshd log"},
+	},
+}
+
+// Doctor step result
+type doctorStep struct {
+	Step    string
+	Status  string
+	Elapsed time.Duration
+	Message string
+}
+
+// Connection with buffered reader (after banner is read)
+type bufferedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// Walk connection pipeline step by step (dns, jump, tcp, banner, kex, auth, channel),
+// and print timings and suggestion of first failing step.
+func Doctor(connectServer string, confList conf.Config) int {
+	fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+
+	steps := []doctorStep{}
+	failed := ""
+	run := func(step string, f func() (string, error)) bool {
+		startTime := time.Now()
+		message, err := f()
+		result := doctorStep{Step: step, Status: "OK", Elapsed: time.Since(startTime), Message: message}
+		if err != nil {
+			result.Status = "NG"
+			result.Message = err.Error()
+			failed = step
+		}
+		steps = append(steps, result)
+		return err == nil
+	}
+
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+	config, err := createSshClientConfig(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var conn net.Conn
+	var reader *bufio.Reader
+	var sshConn ssh.Conn
+	var channels <-chan ssh.NewChannel
+	var requests <-chan *ssh.Request
+	kexTime := time.Time{}
+	authStart := time.Time{}
+
+	ok := true
+	if serverConf.ProxyJump == "" {
+		ok = run("dns", func() (string, error) {
+			if net.ParseIP(serverConf.Addr) != nil {
+				return "ip address", nil
+			}
+			addrs, err := net.LookupHost(serverConf.Addr)
+			return strings.Join(addrs, ", "), err
+		}) && run("tcp", func() (message string, err error) {
+			conn, err = dialTcp(connectServer, confList, addr, config.Timeout)
+			if err == nil {
+				message = conn.RemoteAddr().String()
+			}
+			return
+		})
+	} else {
+		ok = run("jump", func() (message string, err error) {
+			conn, err = dialProxyJump(connectServer, confList, addr, config.Timeout)
+			return serverConf.ProxyJump, err
+		})
+	}
+
+	ok = ok && run("banner", func() (string, error) {
+		conn.SetReadDeadline(time.Now().Add(config.Timeout))
+		defer conn.SetReadDeadline(time.Time{})
+		reader = bufio.NewReader(conn)
+		rawLine, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line := strings.TrimSpace(rawLine)
+		if !strings.HasPrefix(line, "SSH-") {
+			return "", fmt.Errorf("not ssh server (%s)", line)
+		}
+
+		// replay banner at handshake
+		conn = &bufferedConn{Conn: conn, reader: io.MultiReader(strings.NewReader(rawLine), reader)}
+		return line, nil
+	})
+
+	hostKeyMessage := ""
+	if ok {
+		hostKeyCallback := config.HostKeyCallback
+		config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			kexTime = time.Now()
+			hostKeyMessage = key.Type() + " " + ssh.FingerprintSHA256(key)
+			return hostKeyCallback(hostname, remote, key)
+		}
+
+		// kex and auth is done in NewClientConn, split by host key callback time
+		startTime := time.Now()
+		conn.SetDeadline(time.Now().Add(config.Timeout))
+		sshConn, channels, requests, err = ssh.NewClientConn(conn, addr, config)
+		conn.SetDeadline(time.Time{})
+		authStart = kexTime
+		if kexTime.IsZero() || strings.Contains(fmt.Sprint(err), "knownhosts") {
+			steps = append(steps, doctorStep{Step: "kex", Status: "NG", Elapsed: time.Since(startTime), Message: fmt.Sprint(err)})
+			failed = "kex"
+			ok = false
+		} else {
+			steps = append(steps, doctorStep{Step: "kex", Status: "OK", Elapsed: kexTime.Sub(startTime), Message: hostKeyMessage})
+			result := doctorStep{Step: "auth", Status: "OK", Elapsed: time.Since(authStart), Message: serverConf.User}
+			if err != nil {
+				result.Status = "NG"
+				result.Message = err.Error()
+				failed = "auth"
+				ok = false
+			}
+			steps = append(steps, result)
+		}
+	}
+
+	if ok {
+		client := ssh.NewClient(sshConn, channels, requests)
+		defer client.Close()
+		run("channel", func() (string, error) {
+			session, err := client.NewSession()
+			if err != nil {
+				return "", err
+			}
+			defer session.Close()
+			return "session", session.Run("exit 0")
+		})
+	} else if conn != nil {
+		conn.Close()
+	}
+
+	// Print steps
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, s := range steps {
+		fmt.Fprintf(w, "[%s]\t%s\t%s\t%s\t\n", s.Status, s.Step, s.Elapsed.Round(time.Millisecond), s.Message)
+	}
+	w.Flush()
+
+	if failed == "" {
+		fmt.Println("All steps are OK.")
+		return 0
+	}
+
+	// suggestion
+	message := steps[len(steps)-1].Message
+	for _, suggestion := range doctorSuggestions[failed] {
+		if strings.Contains(message, suggestion[0]) {
+			fmt.Printf("\nFailed at %s: %s\n", failed, suggestion[1])
+			break
+		}
+	}
+	return 1
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// luit charset name (use terminal connect)
+var luitEncodingNames = map[string]string{
+	"shift_jis": "SJIS",
+	"sjis":      "SJIS",
+	"cp932":     "SJIS",
+	"euc-jp":    "eucJP",
+	"euc-kr":    "eucKR",
+	"euc-tw":    "eucTW",
+	"gbk":       "GBK",
+	"gb18030":   "GB18030",
+	"big5":      "Big5",
+}
+
+// Get encoding from config value (ex. "shift_jis", "euc-kr")
+func getEncoding(name string) (enc encoding.Encoding, err error) {
+	enc, err = ianaindex.IANA.Encoding(name)
+	if err == nil && enc == nil {
+		err = fmt.Errorf("encoding %s is not supported", name)
+	}
+	return
+}
+
+// Create luit command line. luit converts the pty stream to/from UTF-8.
+func getLuitCmd(name string) (luitCmd string, err error) {
+	luitName, ok := luitEncodingNames[strings.ToLower(name)]
+	if !ok {
+		err = fmt.Errorf("encoding %s is not supported at terminal connect", name)
+		return
+	}
+	luitPath, err := exec.LookPath("luit")
+	if err != nil {
+		err = fmt.Errorf("encoding %s needs luit command at terminal connect: %v", name, err)
+		return
+	}
+	luitCmd = shellQuote(luitPath) + " -encoding " + luitName + " --"
+	return
+}
+
+// Convert remote encoding stream to UTF-8
+func newDecodeWriter(w io.Writer, enc encoding.Encoding) io.WriteCloser {
+	return transform.NewWriter(w, enc.NewDecoder())
+}
+
+// Convert UTF-8 stream to remote encoding
+func newEncodeReader(r io.Reader, enc encoding.Encoding) io.Reader {
+	return transform.NewReader(r, enc.NewEncoder())
+}
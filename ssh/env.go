@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+	"golang.org/x/crypto/ssh"
+)
+
+// Get server env names (sorted)
+func getEnvNames(serverConf conf.ReadConfig) (names []string) {
+	for name := range serverConf.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Send server env to session (need AcceptEnv at remote sshd).
+// Same as OpenSSH SetEnv, rejected env is ignored.
+func setSessionEnv(session *ssh.Session, serverConf conf.ReadConfig) {
+	for _, name := range getEnvNames(serverConf) {
+		session.Setenv(name, serverConf.Env[name])
+	}
+}
+
+// Get ssh command SetEnv option value (NAME="value" ...)
+func getSetEnvOption(serverConf conf.ReadConfig) string {
+	envs := []string{}
+	for _, name := range getEnvNames(serverConf) {
+		envs = append(envs, name+"=\""+strings.Replace(serverConf.Env[name], "\"", "", -1)+"\"")
+	}
+	return strings.Join(envs, " ")
+}
@@ -0,0 +1,207 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Health check stage name
+var healthStages = []string{"tcp", "handshake", "auth", "command"}
+
+// Health check option
+type HealthOption struct {
+	ProbeCmd string
+	Parallel int
+	Json     bool
+}
+
+// Health check stage result
+type HealthStage struct {
+	Stage   string  `json:"stage"`
+	Status  string  `json:"status"` // OK, NG, SKIP
+	Elapsed float64 `json:"elapsed"`
+	Message string  `json:"message,omitempty"`
+}
+
+// Health check result (per server)
+type HealthResult struct {
+	Server    string        `json:"server"`
+	Status    string        `json:"status"`
+	Stages    []HealthStage `json:"stages"`
+	ClockSkew *float64      `json:"clock_skew,omitempty"`
+	Warning   string        `json:"warning,omitempty"`
+}
+
+// Health check servers (tcp, handshake, auth, command probe, clock skew), and print table or json
+func HealthCheck(serverList []string, confList conf.Config, option HealthOption) int {
+	if option.Parallel < 1 {
+		option.Parallel = 1
+	}
+
+	results := make([]HealthResult, len(serverList))
+	sem := make(chan struct{}, option.Parallel)
+	wg := &sync.WaitGroup{}
+	for i, server := range serverList {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, server string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = healthCheckServer(server, confList, option)
+		}(i, server)
+	}
+	wg.Wait()
+
+	exitStatus := 0
+	for _, r := range results {
+		if r.Status != "OK" {
+			exitStatus = 1
+		}
+	}
+
+	if option.Json {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+		return exitStatus
+	}
+
+	// Print result table
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ServerName\tStatus\tTCP\tHandshake\tAuth\tCommand\tClockSkew\tMessage\t")
+	for _, r := range results {
+		line := r.Server + "\t" + r.Status + "\t"
+		message := ""
+		for _, stage := range r.Stages {
+			line = line + stage.Status + "\t"
+			if stage.Message != "" && message == "" {
+				message = stage.Stage + ": " + stage.Message
+			}
+		}
+		if message == "" {
+			message = r.Warning
+		}
+		skew := "-"
+		if r.ClockSkew != nil {
+			skew = strconv.FormatFloat(*r.ClockSkew, 'f', 1, 64) + "s"
+		}
+		fmt.Fprintln(w, line+skew+"\t"+message+"\t")
+	}
+	w.Flush()
+	return exitStatus
+}
+
+func healthCheckServer(connectServer string, confList conf.Config, option HealthOption) (result HealthResult) {
+	result.Server = connectServer
+	result.Status = "NG"
+	for _, stage := range healthStages {
+		result.Stages = append(result.Stages, HealthStage{Stage: stage, Status: "SKIP"})
+	}
+	setStage := func(i int, startTime time.Time, err error) bool {
+		result.Stages[i].Elapsed = time.Since(startTime).Seconds()
+		if err != nil {
+			result.Stages[i].Status = "NG"
+			result.Stages[i].Message = err.Error()
+			return false
+		}
+		result.Stages[i].Status = "OK"
+		return true
+	}
+
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+
+	config, err := createSshClientConfig(connectServer, confList)
+	if err != nil {
+		result.Stages[0].Status = "NG"
+		result.Stages[0].Message = err.Error()
+		return
+	}
+
+	// tcp
+	startTime := time.Now()
+	var conn net.Conn
+	if serverConf.ProxyJump != "" {
+		conn, err = dialProxyJump(connectServer, confList, addr, config.Timeout)
+	} else {
+		conn, err = dialTcp(connectServer, confList, addr, config.Timeout)
+	}
+	if !setStage(0, startTime, err) {
+		return
+	}
+	defer conn.Close()
+
+	// handshake (host key is received) and auth
+	startTime = time.Now()
+	handshakeTime := time.Time{}
+	hostKeyCallback := config.HostKeyCallback
+	config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := hostKeyCallback(hostname, remote, key)
+		if err == nil {
+			handshakeTime = time.Now()
+		}
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(config.Timeout))
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, addr, config)
+	conn.SetDeadline(time.Time{})
+	if handshakeTime.IsZero() {
+		setStage(1, startTime, err)
+		return
+	}
+	result.Stages[1].Status = "OK"
+	result.Stages[1].Elapsed = handshakeTime.Sub(startTime).Seconds()
+	if !setStage(2, handshakeTime, err) {
+		return
+	}
+	client := ssh.NewClient(sshConn, channels, requests)
+	defer client.Close()
+
+	// command probe
+	if option.ProbeCmd != "" {
+		startTime = time.Now()
+		err = runProbe(client, option.ProbeCmd)
+		if !setStage(3, startTime, err) {
+			return
+		}
+	}
+
+	// clock skew
+	if skew, err := getClockSkew(client); err == nil {
+		result.ClockSkew = &skew
+		if threshold := getClockSkewThreshold(connectServer, confList); math.Abs(skew) > threshold {
+			result.Warning = fmt.Sprintf("clock skew %.1fs exceeds %.0fs", skew, threshold)
+		}
+	}
+
+	result.Status = "OK"
+	return
+}
+
+// Exec probe command (exit status 0 is OK)
+func runProbe(client *ssh.Client, cmd string) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput(cmd)
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%v (%s)", err, message)
+		}
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Get known_hosts file path (server config, or profile known_hosts)
+func getKnownHostsFile(connectServer string, confList conf.Config) string {
+	if path := confList.Server[connectServer].KnownHostsFile; path != "" {
+		usr, _ := user.Current()
+		return strings.Replace(path, "~", usr.HomeDir, 1)
+	}
+	return conf.GetProfileKnownHosts()
+}
+
+// Get host key check callback ("strict_host_key_checking" is not set, not check)
+func getHostKeyCallback(connectServer string, confList conf.Config) (callback ssh.HostKeyCallback, err error) {
+	mode := strings.ToLower(confList.Server[connectServer].StrictHostKeyChecking)
+	switch mode {
+	case "", "no", "off":
+		return ssh.InsecureIgnoreHostKey(), nil
+	case "yes", "ask", "accept-new":
+	default:
+		return nil, fmt.Errorf("%s: 'strict_host_key_checking' %s is not valid value", connectServer, mode)
+	}
+
+	path := getKnownHostsPath(connectServer, confList)
+	knownHostsCallback, err := getKnownHostsCallback(path)
+	if err != nil {
+		return
+	}
+
+	callback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := knownHostsCallback(hostname, remote, key)
+		if keyErr, ok := err.(*knownhosts.KeyError); ok && len(keyErr.Want) == 0 && mode == "accept-new" {
+			return appendKnownHosts(path, hostname, key, false)
+		}
+		return err
+	}
+	return
+}
+
+// Get known_hosts file path (default is ~/.ssh/known_hosts)
+func getKnownHostsPath(connectServer string, confList conf.Config) string {
+	path := getKnownHostsFile(connectServer, confList)
+	if path == "" {
+		usr, _ := user.Current()
+		path = filepath.Join(usr.HomeDir, ".ssh", "known_hosts")
+	}
+	return path
+}
+
+// Get known_hosts callback (create empty known_hosts file, if not exist)
+func getKnownHostsCallback(path string) (callback ssh.HostKeyCallback, err error) {
+	if _, err = os.Stat(path); os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return
+		}
+		if err = os.WriteFile(path, nil, 0600); err != nil {
+			return
+		}
+	}
+	return knownhosts.New(path)
+}
+
+// Add host key to known_hosts file (hash is hashed hostname)
+func appendKnownHosts(path string, hostname string, key ssh.PublicKey, hash bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, getKnownHostsLine(hostname, key, hash))
+	return err
+}
+
+// Get known_hosts line
+func getKnownHostsLine(hostname string, key ssh.PublicKey, hash bool) string {
+	hostname = knownhosts.Normalize(hostname)
+	if hash {
+		hostname = knownhosts.HashHostname(hostname)
+	}
+	return knownhosts.Line([]string{hostname}, key)
+}
@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Connection over jump hosts (close jump host clients at Close)
+type jumpConn struct {
+	net.Conn
+	clients []*ssh.Client
+}
+
+func (c *jumpConn) Close() error {
+	err := c.Conn.Close()
+	for i := len(c.clients) - 1; i >= 0; i-- {
+		c.clients[i].Close()
+	}
+	return err
+}
+
+// Get jump host server config.
+// If jump host is server name in config, use it. Else, use target server auth.
+func getJumpServer(jumpHost string, connectServer string, confList conf.Config) (conf.ReadConfig, error) {
+	if serverConf, ok := confList.Server[jumpHost]; ok {
+		return serverConf, nil
+	}
+
+	serverConf := confList.Server[connectServer]
+	serverConf.ProxyJump = ""
+
+	if i := strings.LastIndex(jumpHost, "@"); i >= 0 {
+		serverConf.User = jumpHost[:i]
+		jumpHost = jumpHost[i+1:]
+	}
+	host, port, err := conf.SplitHostPort(jumpHost)
+	if err != nil {
+		return serverConf, err
+	}
+	if port == "" {
+		port = conf.DefaultPort
+	}
+	serverConf.Addr = host
+	serverConf.Port = port
+	return serverConf, nil
+}
+
+// Dial addr over jump hosts ("proxy_jump")
+func dialProxyJump(connectServer string, confList conf.Config, addr string, timeout time.Duration) (conn net.Conn, err error) {
+	// copy server config map (add jump host server config)
+	jumpConf := confList
+	jumpConf.Server = map[string]conf.ReadConfig{}
+	for k, v := range confList.Server {
+		jumpConf.Server[k] = v
+	}
+
+	clients := []*ssh.Client{}
+	closeClients := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+
+	for i, jumpHost := range strings.Split(confList.Server[connectServer].ProxyJump, ",") {
+		jumpHost = strings.TrimSpace(jumpHost)
+		jumpServer, jumpErr := getJumpServer(jumpHost, connectServer, confList)
+		if jumpErr != nil {
+			closeClients()
+			return nil, jumpErr
+		}
+		jumpName := fmt.Sprintf("%s (jump %d)", jumpHost, i+1)
+		jumpConf.Server[jumpName] = jumpServer
+
+		config, reporter, configErr := createSshClientConfigWithReporter(jumpName, jumpConf)
+		if configErr != nil {
+			closeClients()
+			return nil, configErr
+		}
+
+		jumpHostPort := net.JoinHostPort(jumpServer.Addr, jumpServer.Port)
+		var jumpConnection net.Conn
+		if len(clients) == 0 {
+			jumpConnection, err = dialTcp(jumpName, jumpConf, jumpHostPort, timeout)
+		} else {
+			jumpConnection, err = clients[len(clients)-1].Dial("tcp", jumpHostPort)
+		}
+		if err != nil {
+			closeClients()
+			return nil, fmt.Errorf("cannot connect jump host %v: %v", jumpHostPort, err)
+		}
+
+		sshConn, channels, requests, connErr := ssh.NewClientConn(jumpConnection, jumpHostPort, config)
+		if connErr != nil {
+			reporter.reportRejected(connErr)
+			jumpConnection.Close()
+			closeClients()
+			return nil, fmt.Errorf("cannot connect jump host %v: %v", jumpHostPort, connErr)
+		}
+		clients = append(clients, ssh.NewClient(sshConn, channels, requests))
+	}
+
+	conn, err = clients[len(clients)-1].Dial("tcp", addr)
+	if err != nil {
+		closeClients()
+		return
+	}
+	conn = &jumpConn{Conn: conn, clients: clients}
+	return
+}
+
+// Get ssh -J arg (server name in config is replaced to "user@addr:port")
+func getProxyJumpArg(proxyJump string, confList conf.Config) string {
+	jumpHosts := []string{}
+	for _, jumpHost := range strings.Split(proxyJump, ",") {
+		jumpHost = strings.TrimSpace(jumpHost)
+		if serverConf, ok := confList.Server[jumpHost]; ok {
+			jumpHost = serverConf.User + "@" + net.JoinHostPort(serverConf.Addr, serverConf.Port)
+		}
+		jumpHosts = append(jumpHosts, jumpHost)
+	}
+	return strings.Join(jumpHosts, ",")
+}
@@ -0,0 +1,172 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Host key algorithms to collect (one key per key type)
+var keyscanAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+}
+
+var errKeyscanStop = errors.New("keyscan: host key is received")
+
+// Keyscan option
+type KeyscanOption struct {
+	Parallel int
+	Hash     bool
+	Print    bool
+}
+
+// Collected host key
+type keyscanKey struct {
+	server   string
+	hostname string
+	key      ssh.PublicKey
+	status   string // new, known, changed
+}
+
+// Collect host keys of servers (over proxy_jump), and add new keys to known_hosts.
+// Changed host keys are reported and not written.
+func Keyscan(serverList []string, confList conf.Config, option KeyscanOption) int {
+	if option.Parallel < 1 {
+		option.Parallel = 1
+	}
+
+	keys := make([][]keyscanKey, len(serverList))
+	errs := make([]error, len(serverList))
+	sem := make(chan struct{}, option.Parallel)
+	wg := &sync.WaitGroup{}
+	for i, server := range serverList {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, server string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			keys[i], errs[i] = keyscanServer(server, getBackgroundConfig(server, confList))
+		}(i, server)
+	}
+	wg.Wait()
+
+	exitStatus := 0
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ServerName\tStatus\tKeyType\tFingerprint\t")
+	for i, server := range serverList {
+		if errs[i] != nil {
+			fmt.Fprintf(w, "%s\tNG\t\t%s\t\n", server, errs[i])
+			exitStatus = 1
+			continue
+		}
+
+		path := getKnownHostsPath(server, confList)
+		for _, k := range keys[i] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", server, k.status, k.key.Type(), ssh.FingerprintSHA256(k.key))
+			switch k.status {
+			case "changed":
+				exitStatus = 1
+			case "new":
+				if option.Print {
+					fmt.Println(getKnownHostsLine(k.hostname, k.key, option.Hash))
+				} else if err := appendKnownHosts(path, k.hostname, k.key, option.Hash); err != nil {
+					fmt.Fprintf(w, "%s\tNG\t\t%s\t\n", server, err)
+					exitStatus = 1
+				}
+			}
+		}
+	}
+	w.Flush()
+	return exitStatus
+}
+
+// Get host keys of server (handshake only), and check with known_hosts
+func keyscanServer(connectServer string, confList conf.Config) (keys []keyscanKey, err error) {
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+	timeout := 10 * time.Second
+	if serverConf.ConnectTimeout > 0 {
+		timeout = time.Duration(serverConf.ConnectTimeout) * time.Second
+	}
+
+	knownHostsCallback, err := getKnownHostsCallback(getKnownHostsPath(connectServer, confList))
+	if err != nil {
+		return
+	}
+
+	types := map[string]bool{}
+	var lastErr error
+	for _, algorithm := range keyscanAlgorithms {
+		key, remote, scanErr := scanHostKey(connectServer, confList, addr, algorithm, timeout)
+		if scanErr != nil {
+			lastErr = scanErr
+			continue
+		}
+		if types[key.Type()] {
+			continue
+		}
+		types[key.Type()] = true
+
+		k := keyscanKey{server: connectServer, hostname: addr, key: key, status: "known"}
+		checkErr := knownHostsCallback(addr, remote, key)
+		if keyErr, ok := checkErr.(*knownhosts.KeyError); ok {
+			k.status = "new"
+			for _, want := range keyErr.Want {
+				if want.Key.Type() == key.Type() {
+					k.status = "changed"
+				}
+			}
+		} else if checkErr != nil {
+			err = checkErr
+			return
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		err = lastErr
+	}
+	return
+}
+
+// Get host key of algorithm, and remote address
+func scanHostKey(connectServer string, confList conf.Config, addr string, algorithm string, timeout time.Duration) (key ssh.PublicKey, remote net.Addr, err error) {
+	var conn net.Conn
+	if confList.Server[connectServer].ProxyJump != "" {
+		conn, err = dialProxyJump(connectServer, confList, addr, timeout)
+	} else {
+		conn, err = dialTcp(connectServer, confList, addr, timeout)
+	}
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	config := &ssh.ClientConfig{
+		User:              confList.Server[connectServer].User,
+		HostKeyAlgorithms: []string{algorithm},
+		HostKeyCallback: func(hostname string, r net.Addr, k ssh.PublicKey) error {
+			key, remote = k, r
+			return errKeyscanStop
+		},
+	}
+	_, _, _, err = ssh.NewClientConn(conn, addr, config)
+	if key != nil {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+// Check tcp port reachability from remote server (direct-tcpip).
+// If untilOpen, wait until port is open (timeout 0 is wait forever).
+func Probe(connectServer string, confList conf.Config, target string, untilOpen bool, timeout time.Duration) int {
+	fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer client.Close()
+
+	// remote side dial (polling with backoff)
+	limit := time.Now().Add(timeout)
+	interval := time.Second
+	for {
+		startTime := time.Now()
+		conn, err := client.Dial("tcp", target)
+		if err == nil {
+			conn.Close()
+			fmt.Printf("open    :%s (from %s, %s)\n", target, connectServer, time.Since(startTime).Round(time.Millisecond))
+			return 0
+		}
+
+		if !untilOpen || (timeout > 0 && time.Now().After(limit)) {
+			fmt.Printf("closed  :%s (from %s, %v)\n", target, connectServer, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Waiting port :%s (%v)\n", target, err)
+
+		time.Sleep(interval)
+		if interval < maxWaitInterval {
+			interval *= 2
+		}
+	}
+}
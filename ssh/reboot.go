@@ -0,0 +1,149 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Reboot option
+type RebootOption struct {
+	RebootCmd string
+	HealthCmd string
+	Parallel  int
+	Timeout   time.Duration
+}
+
+// Reboot result (per server)
+type rebootResult struct {
+	Server   string
+	Status   string
+	Downtime time.Duration
+	Elapsed  time.Duration
+	Message  string
+}
+
+// Reboot servers, wait for come back and verify (rolling)
+func Reboot(serverList []string, confList conf.Config, option RebootOption) int {
+	if option.Parallel < 1 {
+		option.Parallel = 1
+	}
+
+	results := make([]rebootResult, len(serverList))
+	sem := make(chan struct{}, option.Parallel)
+	wg := &sync.WaitGroup{}
+	for i, server := range serverList {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, server string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = rebootServer(server, confList, option)
+			fmt.Fprintf(os.Stderr, "Reboot %s :%s\n", results[i].Status, server)
+		}(i, server)
+	}
+	wg.Wait()
+
+	// Print result table
+	exitStatus := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ServerName\tStatus\tDowntime\tElapsed\tMessage\t")
+	for _, r := range results {
+		if r.Status != "OK" {
+			exitStatus = 1
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", r.Server, r.Status,
+			r.Downtime.Round(time.Second), r.Elapsed.Round(time.Second), r.Message)
+	}
+	w.Flush()
+	return exitStatus
+}
+
+func rebootServer(connectServer string, confList conf.Config, option RebootOption) (result rebootResult) {
+	result.Server = connectServer
+	result.Status = "NG"
+	startTime := time.Now()
+	defer func() { result.Elapsed = time.Since(startTime) }()
+
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+	dial := getServerDial(connectServer, confList)
+
+	// Exec reboot command (connection is closed by remote, ignore error)
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		result.Message = err.Error()
+		return
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		result.Message = err.Error()
+		return
+	}
+	session.Run(option.RebootCmd)
+	session.Close()
+	client.Close()
+	rebootTime := time.Now()
+
+	// Wait for going down
+	limit := rebootTime.Add(option.Timeout)
+	for checkSshBanner(dial, addr) == nil {
+		if time.Now().After(limit) {
+			result.Message = "server did not go down"
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	downTime := time.Now()
+
+	// Wait for coming back (waitSsh waits forever at timeout <= 0)
+	if !time.Now().Before(limit) {
+		result.Message = fmt.Sprintf("%s: wait timeout", addr)
+		return
+	}
+	if err := waitSsh(dial, addr, time.Until(limit)); err != nil {
+		result.Message = err.Error()
+		return
+	}
+	result.Downtime = time.Since(downTime)
+
+	// Verify uptime reset
+	output, err := getRemoteCommandOutput(connectServer, confList, "cat /proc/uptime")
+	if err != nil {
+		result.Message = "uptime check error: " + err.Error()
+		return
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		result.Message = "uptime check error: empty output"
+		return
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		result.Message = "uptime check error: " + err.Error()
+		return
+	}
+	if time.Duration(uptime*float64(time.Second)) > time.Since(rebootTime) {
+		result.Message = "uptime is not reset"
+		return
+	}
+
+	// Health check
+	if option.HealthCmd != "" {
+		if _, err := getRemoteCommandOutput(connectServer, confList, option.HealthCmd); err != nil {
+			result.Message = "health check error: " + err.Error()
+			return
+		}
+	}
+
+	result.Status = "OK"
+	return
+}
@@ -0,0 +1,73 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const defaultRootWarnTitle = "[ROOT] "
+
+// Check remote login user is root (uid probe)
+func isRemoteRoot(connectServer string, confList conf.Config) bool {
+	if confList.Server[connectServer].User == "root" {
+		return true
+	}
+
+	client, err := createSshClient(connectServer, getBackgroundConfig(connectServer, confList))
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+	return isRootClient(client)
+}
+
+// Check uid of ssh client session is 0
+func isRootClient(client *ssh.Client) bool {
+	session, err := client.NewSession()
+	if err != nil {
+		return false
+	}
+	defer session.Close()
+
+	output, err := session.Output("id -u")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "0"
+}
+
+// Show root shell warning, and change terminal color and title (return restore function)
+func warnRootTerminal(connectServer string, confList conf.Config) (restore func()) {
+	restore = func() {}
+	rootWarn := confList.RootWarn
+	if !rootWarn.Enable || !isRemoteRoot(connectServer, confList) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\x1b[1;31mWarning: %s is root shell.\x1b[0m\n", connectServer)
+
+	// background color
+	restoreBackground := setTerminalBackground(rootWarn.Color)
+
+	// title prefix
+	prefix := rootWarn.Title
+	if prefix == "" {
+		prefix = defaultRootWarnTitle
+	}
+	title, err := getTitle(connectServer, confList)
+	if err != nil {
+		title = connectServer
+	}
+	setTerminalTitle(prefix + title)
+
+	restore = func() {
+		restoreBackground()
+		restoreTerminalTitle()
+	}
+	return
+}
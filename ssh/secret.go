@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/keychain"
+)
+
+// Exec secret command (password_cmd, passphrase_cmd), and get first line of stdout.
+// %n: server name, %h: addr, %r: user, %k: key path
+func execSecretCommand(name string, command string, connectServer string, confList conf.Config, keyPath string) (secret string, err error) {
+	serverConf := confList.Server[connectServer]
+	replacer := strings.NewReplacer("%n", connectServer, "%h", serverConf.Addr, "%r", serverConf.User, "%k", keyPath, "%%", "%")
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("/bin/sh", "-c", replacer.Replace(command))
+	cmd.Stderr = stderr
+	cmd.Stdin = os.Stdin
+	data, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("%s error: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+
+	secret = strings.SplitN(string(data), "\n", 2)[0]
+	secret = strings.TrimSuffix(secret, "\r")
+	return
+}
+
+// Get password (pass, or password_cmd output). Empty if not set.
+func getPassword(connectServer string, confList conf.Config) (string, error) {
+	serverConf := confList.Server[connectServer]
+	if serverConf.Pass != "" || serverConf.PasswordCmd == "" {
+		return conf.ResolveSecret(serverConf.Pass, confList.Secrets)
+	}
+	return execSecretCommand("password_cmd", serverConf.PasswordCmd, connectServer, confList, "")
+}
+
+// Get key passphrase (passphrase, passphrase_cmd output, or OS keychain). Empty if not set.
+// fromKeychain is true if passphrase is got from OS keychain.
+func getPassphrase(connectServer string, confList conf.Config, keyPath string) (passphrase string, fromKeychain bool, err error) {
+	serverConf := confList.Server[connectServer]
+	switch {
+	case serverConf.Passphrase != "":
+		passphrase, err = conf.ResolveSecret(serverConf.Passphrase, confList.Secrets)
+	case serverConf.PassphraseCmd != "":
+		passphrase, err = execSecretCommand("passphrase_cmd", serverConf.PassphraseCmd, connectServer, confList, keyPath)
+	case serverConf.Keychain:
+		// empty if not stored (passphrase is asked)
+		passphrase, _ = keychain.Get(keychain.KeyAccount(connectServer, keyPath))
+		fromKeychain = passphrase != ""
+	}
+	return
+}
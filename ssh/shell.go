@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Quote string for remote shell (single quote)
+func shellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", "'\\''", -1) + "'"
+}
+
+// Escape string for embedding in double quote
+func escapeDoubleQuote(str string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$", "\\$", "`", "\\`")
+	return replacer.Replace(str)
+}
+
+// Get locale env assignment ("LANG=en_US.UTF-8 LC_ALL=en_US.UTF-8 ")
+func getLocaleEnv(connectServer string, confList conf.Config) string {
+	locale := confList.Server[connectServer].ForceLocale
+	if locale == "" {
+		return ""
+	}
+	return "LANG=" + shellQuote(locale) + " LC_ALL=" + shellQuote(locale) + " "
+}
+
+// Get remote interactive shell command (shell override, locale, rc files).
+// If not needed, return empty string.
+func getRemoteShellCmd(connectServer string, confList conf.Config, rcDir string) string {
+	shell := confList.Server[connectServer].Shell
+
+	// rc files need bash
+	if rcDir != "" {
+		shellBin := "bash"
+		if fields := strings.Fields(shell); len(fields) > 0 && filepath.Base(fields[0]) == "bash" {
+			shellBin = fields[0]
+		}
+		shell = shellBin + " --rcfile " + rcDir + "/rc -i"
+	}
+
+	localeEnv := getLocaleEnv(connectServer, confList)
+	if shell == "" && localeEnv == "" {
+		return ""
+	}
+	if shell == "" {
+		shell = "\"$SHELL\" -l"
+	}
+	return localeEnv + "exec " + shell
+}
+
+// Get remote exec command (shell override, locale)
+func getRemoteExecCmd(connectServer string, confList conf.Config, cmd string) string {
+	if shell := confList.Server[connectServer].Shell; shell != "" {
+		cmd = shell + " -c " + shellQuote(cmd)
+	}
+	return getLocaleEnv(connectServer, confList) + cmd
+}
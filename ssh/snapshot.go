@@ -0,0 +1,41 @@
+package ssh
+
+import (
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/snapshot"
+)
+
+// Save remote environment snapshot ("snapshot" is enabled only, errors are ignored)
+func saveSnapshot(connectServer string, confList conf.Config, client *ssh.Client) {
+	if !confList.Server[connectServer].Snapshot {
+		return
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	output, err := session.Output(snapshot.Command)
+	if err != nil {
+		return
+	}
+	snapshot.Save(connectServer, snapshot.Parse(string(output)))
+}
+
+// Save snapshot at terminal connect (use new connection)
+func saveSnapshotTerminal(connectServer string, confList conf.Config) {
+	if !confList.Server[connectServer].Snapshot {
+		return
+	}
+
+	client, err := createSshClient(connectServer, getBackgroundConfig(connectServer, confList))
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	saveSnapshot(connectServer, confList, client)
+}
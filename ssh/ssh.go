@@ -2,17 +2,20 @@ package ssh
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/user"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 
 	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
 	"github.com/shavac/gexpect"
 )
 
@@ -31,18 +34,141 @@ func ConnectSshTerminal(connectServer string, confList conf.Config, execRemoteCm
 	} else {
 		connectPort = confList.Server[connectServer].Port
 	}
-	connectPass := confList.Server[connectServer].Pass
-	connectKey := confList.Server[connectServer].Key
+	connectPass, err := getPassword(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	connectEncoding := confList.Server[connectServer].Encoding
 	connectHost := connectUser + "@" + connectAddr
 
+	// Host key check (default is "no")
+	strictHostKeyChecking := "no"
+	if confList.Server[connectServer].StrictHostKeyChecking != "" {
+		strictHostKeyChecking = confList.Server[connectServer].StrictHostKeyChecking
+	}
+	sshOptions := "-o 'StrictHostKeyChecking " + strictHostKeyChecking + "' -o 'NumberOfPasswordPrompts 1' "
+
 	// ssh command Args
 	sshCmd := ""
-	if connectKey != "" {
-		// "/usr/bin/ssh -o 'StrictHostKeyChecking no' -o 'NumberOfPasswordPrompts 1' -i connectKey connectUser@connectAddr -p connectPort"
-		sshCmd = "/usr/bin/ssh -o 'StrictHostKeyChecking no' -o 'NumberOfPasswordPrompts 1' -i " + connectKey + " " + connectHost + " -p " + connectPort
+	if identities := getIdentities(connectServer, confList); len(identities) > 0 {
+		// "/usr/bin/ssh -o 'StrictHostKeyChecking no' -o 'NumberOfPasswordPrompts 1' -i connectKey [-i identity...] connectUser@connectAddr -p connectPort"
+		identityArgs := ""
+		for _, identity := range identities {
+			identityArgs = identityArgs + "-i " + identity + " "
+		}
+		sshCmd = "/usr/bin/ssh " + sshOptions + identityArgs + connectHost + " -p " + connectPort
 	} else {
 		// "/usr/bin/ssh -o 'StrictHostKeyChecking no' -o 'NumberOfPasswordPrompts 1' connectUser@connectAddr -p connectPort"
-		sshCmd = "/usr/bin/ssh -o 'StrictHostKeyChecking no' -o 'NumberOfPasswordPrompts 1' " + connectHost + " -p " + connectPort
+		sshCmd = "/usr/bin/ssh " + sshOptions + connectHost + " -p " + connectPort
+	}
+
+	// ssh command args
+	for _, sshArg := range confList.Server[connectServer].SshArgs {
+		sshCmd = sshCmd + " " + shellQuote(sshArg)
+	}
+
+	// known_hosts file (server config or profile)
+	if knownHosts := getKnownHostsFile(connectServer, confList); knownHosts != "" {
+		sshCmd = sshCmd + " -o 'UserKnownHostsFile " + knownHosts + "'"
+	}
+
+	// jump hosts
+	if proxyJump := confList.Server[connectServer].ProxyJump; proxyJump != "" {
+		sshCmd = sshCmd + " -J " + shellQuote(getProxyJumpArg(proxyJump, confList))
+	}
+
+	// connect timeout
+	if timeout := confList.Server[connectServer].ConnectTimeout; timeout > 0 {
+		sshCmd = sshCmd + " -o 'ConnectTimeout " + strconv.Itoa(timeout) + "'"
+	}
+
+	// crypto algorithms
+	policy, err := conf.GetCryptoPolicy(confList.Server[connectServer])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, option := range []struct {
+		name  string
+		value []string
+	}{
+		{"Ciphers", policy.Ciphers},
+		{"MACs", policy.Macs},
+		{"KexAlgorithms", policy.KexAlgorithms},
+		{"HostKeyAlgorithms", policy.HostKeyAlgorithms},
+	} {
+		if len(option.value) > 0 {
+			sshCmd = sshCmd + " -o " + shellQuote(option.name+" "+strings.Join(option.value, ","))
+		}
+	}
+
+	// rekey limit
+	if rekeyLimit := confList.Server[connectServer].RekeyLimit; rekeyLimit != "" {
+		sshCmd = sshCmd + " -o " + shellQuote("RekeyLimit "+rekeyLimit)
+	}
+
+	// ssh certificate
+	if confList.Server[connectServer].CertCommand != "" {
+		certPath, _, err := getCertificate(connectServer, confList)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		sshCmd = sshCmd + " -o 'CertificateFile " + certPath + "'"
+	}
+
+	// keepalive
+	if interval := confList.Server[connectServer].KeepaliveInterval; interval > 0 {
+		sshCmd = sshCmd + " -o 'ServerAliveInterval " + strconv.Itoa(interval) + "'"
+	}
+
+	// Suppress ssh banner
+	if confList.Server[connectServer].Quiet {
+		sshCmd = sshCmd + " -o 'LogLevel QUIET'"
+	}
+
+	// Send COLORTERM (need AcceptEnv at remote sshd)
+	if os.Getenv("COLORTERM") != "" {
+		sshCmd = sshCmd + " -o 'SendEnv COLORTERM'"
+	}
+
+	// Server env
+	if len(confList.Server[connectServer].Env) > 0 {
+		sshCmd = sshCmd + " -o " + shellQuote("SetEnv "+getSetEnvOption(confList.Server[connectServer]))
+	}
+
+	// Encoding convert (use luit)
+	if connectEncoding != "" {
+		luitCmd, err := getLuitCmd(connectEncoding)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		sshCmd = luitCmd + " " + sshCmd
+	}
+
+	// Start shell with uploaded rc files, shell override and locale
+	if len(execRemoteCmd) == 0 {
+		rcDir := ""
+		if len(confList.Server[connectServer].RcFiles) > 0 || confList.Server[connectServer].ShellIntegration {
+			var err error
+			rcDir, err = sendRcFiles(connectServer, confList)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+
+		if shellCmd := getRemoteShellCmd(connectServer, confList, rcDir); shellCmd != "" {
+			sshCmd = sshCmd + " -t " + shellQuote(shellCmd)
+		}
+	}
+
+	// exec_command option check
+	if len(execRemoteCmd) != 0 {
+		execRemoteCmdString := strings.Join(execRemoteCmd, " ")
+		sshCmd = sshCmd + " -t " + shellQuote(getRemoteExecCmd(connectServer, confList, execRemoteCmdString))
 	}
 
 	// log Enable
@@ -62,14 +188,13 @@ func ConnectSshTerminal(connectServer string, confList conf.Config, execRemoteCm
 		}
 
 		// Golang time.format YYYYmmdd_HHMMSS = "20060102_150405".(https://golang.org/src/time/format.go)
-		logFile := time.Now().Format("20060102_150405") + "_" + connectServer + ".log"
+		logFile := time.Now().Format("20060102_150405") + "_" + conf.EscapeFileName(connectServer) + ".log"
 		logFilePATH := logDirPath + "/" + logFile
 		awkCmd := ">(awk '{print strftime(\"%F %T \") $0}{fflush() }'>>" + logFilePATH + ")"
 
 		// exec_command option check
 		if len(execRemoteCmd) != 0 {
 			execRemoteCmdString := strings.Join(execRemoteCmd, " ")
-			sshCmd = sshCmd + " " + execRemoteCmdString
 			logHeadContent := []byte("Exec command: " + execRemoteCmdString + "\n\n" +
 				"=============================\n")
 			ioutil.WriteFile(logFilePATH, logHeadContent, os.ModePerm)
@@ -77,7 +202,7 @@ func ConnectSshTerminal(connectServer string, confList conf.Config, execRemoteCm
 
 		// OS check
 		if execOS == "linux" || execOS == "android" {
-			execCmd = "/usr/bin/script -qf -c \"" + sshCmd + "\" " + awkCmd
+			execCmd = "/usr/bin/script -qf -c \"" + escapeDoubleQuote(sshCmd) + "\" " + awkCmd
 		} else {
 			execCmd = "/usr/bin/script -qF " + awkCmd + " " + sshCmd
 		}
@@ -87,7 +212,44 @@ func ConnectSshTerminal(connectServer string, confList conf.Config, execRemoteCm
 	}
 
 	// Print selected server and connect command
-	fmt.Fprintf(os.Stderr, "Select Server :%s\n", connectServer)
+	fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+
+	// Clock skew warning
+	warnClockSkewTerminal(connectServer, confList)
+
+	// Set terminal title
+	if confList.Title.Enable {
+		title, err := getTitle(connectServer, confList)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		setTerminalTitle(title)
+		defer restoreTerminalTitle()
+	}
+
+	// Terminal background color
+	restoreBackground := setTerminalBackground(confList.Server[connectServer].Color)
+	defer restoreBackground()
+
+	// Root shell warning
+	restoreRootWarn := warnRootTerminal(connectServer, confList)
+	defer restoreRootWarn()
+
+	// Set TERM (and send terminfo)
+	term := getRemoteTerm(connectServer, confList)
+	if confList.Server[connectServer].Terminfo && term != "" {
+		if err := sendTerminfo(connectServer, confList, term); err != nil {
+			fmt.Fprintf(os.Stderr, "terminfo send error: %v\n", err)
+			term = fallbackTerm
+		}
+	}
+	if term != "" {
+		os.Setenv("TERM", term)
+	}
+
+	// Save remote environment snapshot (background)
+	go saveSnapshotTerminal(connectServer, confList)
 
 	// exec ssh command
 	child, _ := gexpect.NewSubProcess("/bin/bash", "-c", execCmd)
@@ -105,7 +267,7 @@ func ConnectSshTerminal(connectServer string, confList conf.Config, execRemoteCm
 			child.SendLine(connectPass)
 
 		} else {
-			fmt.Println("ssh connect timeout.")
+			fmt.Println(i18n.T(i18n.ConnectTimeout))
 			return 1
 		}
 	}
@@ -122,83 +284,99 @@ func ConnectSshCommand(connectServer string, confList conf.Config, execRemoteCmd
 	//logDirPath := confList.Log.Dir
 
 	// Get ssh config value
-	connectUser := confList.Server[connectServer].User
-	connectAddr := confList.Server[connectServer].Addr
-	var connectPort string
-	if confList.Server[connectServer].Port == "" {
-		connectPort = "22"
-	} else {
-		connectPort = confList.Server[connectServer].Port
-	}
-	connectPass := confList.Server[connectServer].Pass
-	connectKey := confList.Server[connectServer].Key
-
-	// Set ssh client config
-	config := &ssh.ClientConfig{}
-	if connectKey != "" {
-		// Read PublicKey
-		buffer, err := ioutil.ReadFile(connectKey)
-		if err != nil {
-			return 1
-		}
-		key, err := ssh.ParsePrivateKey(buffer)
-		if err != nil {
-			return 1
-		}
-
-		// Create ssh client config for KeyAuth
-		config = &ssh.ClientConfig{
-			User: connectUser,
-			Auth: []ssh.AuthMethod{
-				ssh.PublicKeys(key)},
-			Timeout: 60 * time.Second,
-		}
-	} else {
-		// Create ssh client config for PasswordAuth
-		config = &ssh.ClientConfig{
-			User: connectUser,
-			Auth: []ssh.AuthMethod{
-				ssh.Password(connectPass)},
-			Timeout: 60 * time.Second,
-		}
-	}
+	connectEncoding := confList.Server[connectServer].Encoding
 
-	connectHostPort := connectAddr + ":" + connectPort
-
-	conn, err := ssh.Dial("tcp", connectHostPort, config)
+	conn, err := createSshClient(connectServer, confList)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "cannot connect %v: %v", connectHostPort, err)
+		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
 	defer conn.Close()
 
+	// Clock skew warning
+	warnClockSkew(connectServer, confList, conn)
+
 	session, err := conn.NewSession()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "cannot open new session: %v", err)
 		return 1
 	}
 	defer session.Close()
-
-	go func() {
-		time.Sleep(5 * time.Second)
-		conn.Close()
-	}()
+	setSessionEnv(session, confList.Server[connectServer])
 
 	session.Stdout = os.Stdout
 	session.Stderr = os.Stderr
-	session.Stdin = os.Stdin
+	var stdin io.Reader = os.Stdin
 
 	execRemoteCmdString := strings.Join(execRemoteCmd, " ")
+	runRemoteCmdString := execRemoteCmdString
+
+	// Encoding convert
+	if connectEncoding != "" {
+		enc, err := getEncoding(connectEncoding)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		stdout := newDecodeWriter(os.Stdout, enc)
+		stderr := newDecodeWriter(os.Stderr, enc)
+		defer stdout.Close()
+		defer stderr.Close()
+		session.Stdout = stdout
+		session.Stderr = stderr
+		stdin = newEncodeReader(os.Stdin, enc)
 
-	fmt.Fprintf(os.Stderr, "Select Server :%s\n", connectServer)
-	fmt.Fprintf(os.Stderr, "Exec command  :%s\n", execRemoteCmdString)
+		runRemoteCmdString, err = enc.NewEncoder().String(execRemoteCmdString)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
 
-	err = session.Run(execRemoteCmdString)
+	if !confList.Server[connectServer].Quiet {
+		fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+		fmt.Fprintf(os.Stderr, i18n.T(i18n.ExecCommand), execRemoteCmdString)
+	}
+
+	if err = pipeStdin(session, stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	err = session.Run(getRemoteExecCmd(connectServer, confList, runRemoteCmdString))
+
+	// Save remote environment snapshot
+	saveSnapshot(connectServer, confList, conn)
+
+	return getExitStatus(connectServer, confList, err)
+}
+
+// Copy stdin to session, and send EOF at end of stdin.
+// (session.Stdin is waited at session.Wait, so not returned until stdin is closed)
+func pipeStdin(session *ssh.Session, stdin io.Reader) error {
+	w, err := session.StdinPipe()
 	if err != nil {
-		fmt.Fprint(os.Stderr, err)
-		if ee, ok := err.(*ssh.ExitError); ok {
-			return ee.ExitStatus()
+		return err
+	}
+	go func() {
+		io.Copy(w, stdin)
+		w.Close()
+	}()
+	return nil
+}
+
+// Get exit status from session error
+func getExitStatus(connectServer string, confList conf.Config, err error) int {
+	if err == nil {
+		return 0
+	}
+	if ee, ok := err.(*ssh.ExitError); ok {
+		if !confList.Server[connectServer].Quiet {
+			fmt.Fprint(os.Stderr, err)
 		}
+		return ee.ExitStatus()
 	}
-	return 0
+	fmt.Fprint(os.Stderr, err)
+	return 1
 }
@@ -0,0 +1,38 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+// Start subsystem (ex. "sftp") and connect stdin/stdout
+func ConnectSshSubsystem(connectServer string, confList conf.Config, subsystem string) int {
+	conn, err := createSshClient(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	session, err := conn.NewSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open new session: %v", err)
+		return 1
+	}
+	defer session.Close()
+
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+	if err = pipeStdin(session, os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err = session.RequestSubsystem(subsystem); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot start subsystem %s: %v\n", subsystem, err)
+		return 1
+	}
+	return getExitStatus(connectServer, confList, session.Wait())
+}
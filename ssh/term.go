@@ -0,0 +1,61 @@
+package ssh
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const fallbackTerm = "xterm-256color"
+
+// Local terminal whose terminfo is often not installed at remote server
+var localOnlyTerms = map[string]bool{
+	"xterm-kitty":   true,
+	"xterm-ghostty": true,
+	"alacritty":     true,
+	"wezterm":       true,
+	"foot":          true,
+	"contour":       true,
+}
+
+// Get TERM value for remote server.
+// Use `term` config value, or fallback local only terminal to xterm-256color.
+func getRemoteTerm(connectServer string, confList conf.Config) string {
+	serverConf := confList.Server[connectServer]
+	if serverConf.Term != "" {
+		return serverConf.Term
+	}
+
+	localTerm := os.Getenv("TERM")
+	if localOnlyTerms[localTerm] && !serverConf.Terminfo {
+		return fallbackTerm
+	}
+	return localTerm
+}
+
+// Install local terminfo entry to remote ~/.terminfo, if remote does not have it.
+func sendTerminfo(connectServer string, confList conf.Config, term string) error {
+	terminfo, err := exec.Command("infocmp", "-x", term).Output()
+	if err != nil {
+		return err
+	}
+
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = bytes.NewReader(terminfo)
+	ticCmd := "infocmp " + shellQuote(term) + " >/dev/null 2>&1 && exit 0; " +
+		"f=$(mktemp) && cat > \"$f\" && tic -x \"$f\"; r=$?; rm -f \"$f\"; exit $r"
+	return session.Run(ticCmd)
+}
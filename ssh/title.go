@@ -0,0 +1,96 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const defaultTitleFormat = "{{.User}}@{{.Addr}}"
+
+// Title template value
+type titleValue struct {
+	Name string
+	User string
+	Addr string
+	Port string
+	Note string
+}
+
+// Create terminal title string from config format
+func getTitle(connectServer string, confList conf.Config) (title string, err error) {
+	format := confList.Title.Format
+	if format == "" {
+		format = defaultTitleFormat
+	}
+
+	tmpl, err := template.New("title").Parse(format)
+	if err != nil {
+		return
+	}
+
+	serverConf := confList.Server[connectServer]
+	value := titleValue{
+		Name: connectServer,
+		User: serverConf.User,
+		Addr: serverConf.Addr,
+		Port: serverConf.Port,
+		Note: serverConf.Note,
+	}
+	if value.Port == "" {
+		value.Port = "22"
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, value); err != nil {
+		return
+	}
+
+	// Remove control charactor
+	title = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, buffer.String())
+	return
+}
+
+// Wrap escape sequence for terminal multiplexer
+func wrapTitleSequence(seq string) string {
+	switch {
+	case os.Getenv("TMUX") != "":
+		// tmux passthrough (need `set -g allow-passthrough on`)
+		return "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	case os.Getenv("STY") != "":
+		// screen passthrough
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+// Save current title and set new title (OSC 2)
+func setTerminalTitle(title string) {
+	// push title to xterm title stack
+	fmt.Fprint(os.Stdout, wrapTitleSequence("\x1b[22;0t"))
+	fmt.Fprint(os.Stdout, wrapTitleSequence("\x1b]2;"+title+"\x07"))
+
+	// screen window title
+	if os.Getenv("STY") != "" {
+		fmt.Fprint(os.Stdout, "\x1bk"+title+"\x1b\\")
+	}
+}
+
+// Restore previous title from xterm title stack
+func restoreTerminalTitle() {
+	fmt.Fprint(os.Stdout, wrapTitleSequence("\x1b[23;0t"))
+
+	// screen window title (reset to default)
+	if os.Getenv("STY") != "" {
+		fmt.Fprint(os.Stdout, "\x1bk\x1b\\")
+	}
+}
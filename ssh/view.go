@@ -0,0 +1,181 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/i18n"
+)
+
+const viewHelp = "\r\n[lssh view] input is blocked. Supported escape sequences:\r\n" +
+	"  ~.  - disconnect\r\n" +
+	"  ~?  - this message\r\n"
+
+// Read-only session (local input is blocked except escape sequence ~. and ~?).
+// Scrollback is local terminal's.
+func ConnectSshView(connectServer string, confList conf.Config, execRemoteCmd ...string) int {
+	client, err := createSshClient(connectServer, confList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open new session: %v\n", err)
+		return 1
+	}
+	defer session.Close()
+	setSessionEnv(session, confList.Server[connectServer])
+
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	// Request pty (same size as local terminal)
+	width, height := getTerminalSize()
+	term := getRemoteTerm(connectServer, confList)
+	if term == "" {
+		term = fallbackTerm
+	}
+	if err = session.RequestPty(term, height, width, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot request pty: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, i18n.T(i18n.SelectServer), connectServer)
+	fmt.Fprintf(os.Stderr, "View mode (read-only). Type ~. to disconnect, ~? for help.\n")
+
+	// Local terminal raw mode (restore at exit)
+	restore, err := setRawMode()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer restore()
+
+	// Terminal background color
+	restoreBackground := setTerminalBackground(confList.Server[connectServer].Color)
+	defer restoreBackground()
+
+	// Window resize
+	sigwinch := make(chan os.Signal, 1)
+	signal.Notify(sigwinch, syscall.SIGWINCH)
+	defer signal.Stop(sigwinch)
+	go func() {
+		for range sigwinch {
+			width, height := getTerminalSize()
+			session.WindowChange(height, width)
+		}
+	}()
+
+	// Block input (only watch escape sequence)
+	disconnected := make(chan struct{})
+	go watchViewEscape(session, disconnected)
+
+	if len(execRemoteCmd) > 0 {
+		err = session.Run(strings.Join(execRemoteCmd, " "))
+	} else {
+		if err = session.Shell(); err == nil {
+			err = session.Wait()
+		}
+	}
+	if ee, ok := err.(*ssh.ExitError); ok {
+		return ee.ExitStatus()
+	}
+	if err != nil {
+		// disconnected by ~.
+		select {
+		case <-disconnected:
+			return 0
+		default:
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Read stdin and discard, except escape sequence (~. and ~? at line start).
+// disconnected is closed at ~.
+func watchViewEscape(session *ssh.Session, disconnected chan struct{}) {
+	buf := make([]byte, 1)
+	lineStart := true
+	escape := false
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			return
+		}
+		c := buf[0]
+
+		if escape {
+			escape = false
+			switch c {
+			case '.':
+				close(disconnected)
+				session.Close()
+				return
+			case '?':
+				fmt.Fprint(os.Stderr, viewHelp)
+				continue
+			}
+		}
+		if lineStart && c == '~' {
+			escape = true
+			continue
+		}
+		lineStart = c == '\r' || c == '\n'
+	}
+}
+
+// Get local terminal size (use stty)
+func getTerminalSize() (width, height int) {
+	width, height = 80, 24
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 2 {
+		if h, err := strconv.Atoi(fields[0]); err == nil {
+			height = h
+		}
+		if w, err := strconv.Atoi(fields[1]); err == nil {
+			width = w
+		}
+	}
+	return
+}
+
+// Set local terminal raw mode, and return restore function
+func setRawMode() (restore func(), err error) {
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = os.Stdin
+	state, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("stdin is not terminal: %v", err)
+	}
+
+	cmd = exec.Command("stty", "raw", "-echo")
+	cmd.Stdin = os.Stdin
+	if err = cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	restore = func() {
+		cmd := exec.Command("stty", strings.TrimSpace(string(state)))
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+	return
+}
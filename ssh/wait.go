@@ -0,0 +1,83 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+)
+
+const maxWaitInterval = 30 * time.Second
+
+type dialFunc func(network, addr string) (net.Conn, error)
+
+// Wait until ssh server is reachable. If timeout is 0, wait forever.
+func WaitSsh(connectServer string, confList conf.Config, timeout time.Duration) error {
+	serverConf := confList.Server[connectServer]
+	addr := net.JoinHostPort(serverConf.Addr, serverConf.Port)
+
+	fmt.Fprintf(os.Stderr, "Waiting Server :%s\n", connectServer)
+	return waitSsh(getServerDial(connectServer, confList), addr, timeout)
+}
+
+// Get dial func of server (over jump hosts, if "proxy_jump" is set)
+func getServerDial(connectServer string, confList conf.Config) dialFunc {
+	serverConf := confList.Server[connectServer]
+	timeout := 10 * time.Second
+	if serverConf.ConnectTimeout > 0 {
+		timeout = time.Duration(serverConf.ConnectTimeout) * time.Second
+	}
+
+	return func(network, addr string) (net.Conn, error) {
+		if serverConf.ProxyJump != "" {
+			return dialProxyJump(connectServer, confList, addr, timeout)
+		}
+		return dialTcp(connectServer, confList, addr, timeout)
+	}
+}
+
+// Poll ssh server with backoff
+func waitSsh(dial dialFunc, addr string, timeout time.Duration) error {
+	limit := time.Now().Add(timeout)
+	interval := time.Second
+	for {
+		err := checkSshBanner(dial, addr)
+		if err == nil {
+			return nil
+		}
+
+		if timeout > 0 && time.Now().After(limit) {
+			return fmt.Errorf("%s: wait timeout: %v", addr, err)
+		}
+
+		time.Sleep(interval)
+		if interval < maxWaitInterval {
+			interval *= 2
+		}
+	}
+}
+
+// Check ssh server version banner ("SSH-2.0-...")
+func checkSshBanner(dial dialFunc, addr string) error {
+	conn, err := dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// not supported deadline at ssh channel, ignore error
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "SSH-") {
+		return fmt.Errorf("%s: not ssh server", addr)
+	}
+	return nil
+}
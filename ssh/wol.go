@@ -0,0 +1,84 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/wol"
+)
+
+const defaultWolWait = 300
+
+// Send Wake-on-LAN magic packet (direct or via neighbor server)
+func WakeOnLan(connectServer string, confList conf.Config) error {
+	serverConf := confList.Server[connectServer]
+	if serverConf.Mac == "" {
+		return fmt.Errorf("%s: 'mac' is not inserted", connectServer)
+	}
+
+	if serverConf.WolVia == "" {
+		return wol.Send(serverConf.Mac, serverConf.Broadcast)
+	}
+
+	// Send from neighbor server (need wakeonlan command at neighbor)
+	if _, ok := confList.Server[serverConf.WolVia]; !ok {
+		return fmt.Errorf("%s: wol_via server %s not found", connectServer, serverConf.WolVia)
+	}
+	client, err := createSshClient(serverConf.WolVia, confList)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	wolCmd := "wakeonlan " + serverConf.Mac
+	if serverConf.Broadcast != "" {
+		host, _, err := net.SplitHostPort(serverConf.Broadcast)
+		if err != nil {
+			host = conf.TrimAddrBrackets(serverConf.Broadcast)
+		}
+		wolCmd = "wakeonlan -i " + host + " " + serverConf.Mac
+	}
+	session.Stdout = os.Stderr
+	session.Stderr = os.Stderr
+	return session.Run(wolCmd)
+}
+
+// Send Wake-on-LAN and wait for ssh port
+func WakeUp(connectServer string, confList conf.Config) error {
+	serverConf := confList.Server[connectServer]
+	fmt.Fprintf(os.Stderr, "Wake-on-LAN :%s\n", connectServer)
+	if err := WakeOnLan(connectServer, confList); err != nil {
+		return err
+	}
+
+	port := serverConf.Port
+	if port == "" {
+		port = "22"
+	}
+	wait := serverConf.WolWait
+	if wait == 0 {
+		wait = defaultWolWait
+	}
+
+	// Dial through neighbor server, when target is on remote LAN
+	dial := net.Dial
+	if serverConf.WolVia != "" {
+		client, err := createSshClient(serverConf.WolVia, confList)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		dial = client.Dial
+	}
+
+	return waitSsh(dial, net.JoinHostPort(serverConf.Addr, port), time.Duration(wait)*time.Second)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/blacknon/lssh/compat"
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/ssh"
+)
+
+// lssh [ssh options] user@host [command] (ssh compatible command line)
+func compatCommand(confPath string, cmdArgs []string) int {
+	compatArgs, err := compat.ParseArgs(cmdArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Read config (if exists)
+	listConf := conf.Config{Server: map[string]conf.ReadConfig{}}
+	if _, err := os.Stat(confPath); err == nil {
+		listConf = conf.ConfigCheckRead(confPath)
+	}
+
+	serverName, serverConf, exist := getCompatServer(compatArgs, listConf)
+
+	serverConf, err = applyCompatOptions(serverConf, compatArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	listConf.Server[serverName] = serverConf
+
+	if !exist && listConf.UI.AddPrompt && askAddServer(serverName) {
+		if err := appendServerConfig(confPath, serverName, serverConf); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	return connect(serverName, listConf, compatArgs.Command, false, false, 0)
+}
+
+// lssh --stdio-subsystem [ssh options] host command (use as GIT_SSH_COMMAND, rsync -e).
+// host is server name in config (or user@host), and print nothing other than remote output.
+func stdioCommand(confPath string, cmdArgs []string) int {
+	compatArgs, err := compat.ParseArgs(cmdArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(compatArgs.Command) == 0 {
+		fmt.Fprintln(os.Stderr, "remote command is not specified")
+		return 1
+	}
+
+	// Read config (if exists)
+	listConf := conf.Config{Server: map[string]conf.ReadConfig{}}
+	if _, err := os.Stat(confPath); err == nil {
+		listConf = conf.ConfigCheckRead(confPath)
+	}
+
+	serverName := compatArgs.Host
+	serverConf, ok := listConf.Server[serverName]
+	if ok {
+		if compatArgs.User != "" {
+			serverConf.User = compatArgs.User
+		}
+		if compatArgs.Port != "" {
+			serverConf.Port = compatArgs.Port
+		}
+		if compatArgs.Key != "" {
+			serverConf.Key = compatArgs.Key
+		}
+		if compatArgs.ProxyJump != "" {
+			serverConf.ProxyJump = compatArgs.ProxyJump
+		}
+	} else {
+		serverName, serverConf, _ = getCompatServer(compatArgs, listConf)
+	}
+
+	serverConf, err = applyCompatOptions(serverConf, compatArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	serverConf.Quiet = true
+	listConf.Server[serverName] = serverConf
+
+	if compatArgs.Subsystem {
+		return ssh.ConnectSshSubsystem(serverName, listConf, compatArgs.Command[0])
+	}
+	return connect(serverName, listConf, compatArgs.Command, false, false, 0)
+}
+
+// Translate -o options (unknown options passthrough to ssh)
+func applyCompatOptions(serverConf conf.ReadConfig, compatArgs compat.Args) (conf.ReadConfig, error) {
+	serverConf, unknownOptions, err := compat.ApplyOptions(serverConf, compatArgs.Options)
+	if err != nil {
+		return serverConf, err
+	}
+	for _, option := range unknownOptions {
+		serverConf.SshArgs = append(serverConf.SshArgs, "-o", option)
+	}
+	return serverConf, nil
+}
+
+// Get server config from ssh compatible args.
+// If same user/addr/port server exists in config, use it.
+func getCompatServer(compatArgs compat.Args, listConf conf.Config) (serverName string, serverConf conf.ReadConfig, exist bool) {
+	usr, _ := user.Current()
+	if compatArgs.User == "" {
+		compatArgs.User = usr.Username
+	}
+	if compatArgs.Port == "" {
+		compatArgs.Port = conf.DefaultPort
+	}
+
+	for name, c := range listConf.Server {
+		if c.Addr == compatArgs.Host && c.User == compatArgs.User && c.Port == compatArgs.Port {
+			serverName, serverConf, exist = name, c, true
+			break
+		}
+	}
+
+	if !exist {
+		serverName = compatArgs.ServerName()
+		serverConf = conf.ReadConfig{
+			Addr: compatArgs.Host,
+			Port: compatArgs.Port,
+			User: compatArgs.User,
+		}
+		if compatArgs.Key == "" && len(listConf.Identities) == 0 {
+			serverConf.Identities = conf.GetDefaultIdentities()
+		}
+	}
+
+	if compatArgs.Key != "" {
+		serverConf.Key = compatArgs.Key
+	}
+	if compatArgs.ProxyJump != "" {
+		serverConf.ProxyJump = compatArgs.ProxyJump
+	}
+
+	// passthrough to ssh (terminal connect only)
+	serverConf.SshArgs = append(serverConf.SshArgs, compatArgs.SshArgs...)
+	return
+}
+
+// Ask add ad-hoc server to config
+func askAddServer(serverName string) bool {
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "Add %s to config file? [y/N]: ", serverName)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Append server config to config file
+func appendServerConfig(confPath string, serverName string, serverConf conf.ReadConfig) error {
+	if conf.IsYamlFile(confPath) {
+		return fmt.Errorf("%s: auto add is not supported for yaml config", confPath)
+	}
+	if conf.IsEncryptedFile(confPath) {
+		return fmt.Errorf("%s: auto add is not supported for encrypted config", confPath)
+	}
+
+	f, err := os.OpenFile(confPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "\n[server.%s]\n", strconv.Quote(serverName))
+	fmt.Fprintf(f, "addr = %s\n", strconv.Quote(serverConf.Addr))
+	fmt.Fprintf(f, "port = %s\n", strconv.Quote(serverConf.Port))
+	fmt.Fprintf(f, "user = %s\n", strconv.Quote(serverConf.User))
+	if serverConf.Key != "" {
+		fmt.Fprintf(f, "key = %s\n", strconv.Quote(serverConf.Key))
+	}
+	if len(serverConf.Identities) > 0 {
+		quoted := []string{}
+		for _, identity := range serverConf.Identities {
+			quoted = append(quoted, strconv.Quote(identity))
+		}
+		fmt.Fprintf(f, "identities = [%s]\n", strings.Join(quoted, ", "))
+	}
+	if serverConf.ProxyJump != "" {
+		fmt.Fprintf(f, "proxy_jump = %s\n", strconv.Quote(serverConf.ProxyJump))
+	}
+	return nil
+}
@@ -0,0 +1,789 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	arg "github.com/alexflint/go-arg"
+	"github.com/blacknon/lssh/check"
+	"github.com/blacknon/lssh/conf"
+	"github.com/blacknon/lssh/history"
+	"github.com/blacknon/lssh/i18n"
+	"github.com/blacknon/lssh/key"
+	"github.com/blacknon/lssh/keychain"
+	"github.com/blacknon/lssh/prompt"
+	"github.com/blacknon/lssh/sessionlog"
+	"github.com/blacknon/lssh/ssh"
+	"github.com/blacknon/lssh/update"
+)
+
+// wol sub command option
+type WolCommandOption struct {
+	File string `arg:"-f,help:config file path"`
+	Host string `arg:"positional,required,help:Wake-on-LAN servername"`
+}
+
+// bench sub command option
+type BenchCommandOption struct {
+	File  string `arg:"-f,help:config file path"`
+	Size  int64  `arg:"-s,help:transfer size(MB)"`
+	Count int    `arg:"-c,help:echo count"`
+	Host  string `arg:"positional,required,help:benchmark servername"`
+}
+
+// wait sub command option
+type WaitCommandOption struct {
+	File    string   `arg:"-f,help:config file path"`
+	Timeout int      `arg:"-t,help:wait timeout(sec). 0 is wait forever"`
+	Then    string   `arg:"help:after server is reachable (connect|cmd)"`
+	Host    string   `arg:"positional,required,help:wait servername"`
+	Command []string `arg:"positional,help:Remote Server exec command (--then cmd)."`
+}
+
+// reboot sub command option
+type RebootCommandOption struct {
+	File      string   `arg:"-f,help:config file path"`
+	RebootCmd string   `arg:"--reboot-cmd,help:remote reboot command"`
+	HealthCmd string   `arg:"--health-cmd,help:remote health check command after reboot"`
+	Parallel  int      `arg:"-P,help:reboot server concurrency"`
+	Timeout   int      `arg:"-t,help:wait timeout(sec) per server"`
+	Host      []string `arg:"positional,required,help:reboot servername"`
+}
+
+// keygen sub command option
+type KeygenCommandOption struct {
+	File   string   `arg:"-f,help:config file path"`
+	Type   string   `arg:"-t,help:key type (ed25519|ecdsa|rsa)"`
+	Output string   `arg:"-o,help:private key path [default: ~/.ssh/lssh_<type>]"`
+	Expire int      `arg:"help:key expire days at authorized_keys (expiry-time option)"`
+	Deploy []string `arg:"help:deploy public key to servers"`
+}
+
+// check sub command option
+type CheckCommandOption struct {
+	File     string   `arg:"-f,help:config file path"`
+	ProbeCmd string   `arg:"--cmd,help:remote probe command (exit status 0 is OK)"`
+	Parallel int      `arg:"-P,help:check server concurrency"`
+	Json     bool     `arg:"help:output json report"`
+	Host     []string `arg:"positional,help:check servername [default: all servers]"`
+}
+
+// audit sub command option
+type AuditCommandOption struct {
+	File     string   `arg:"-f,help:config file path"`
+	Parallel int      `arg:"-P,help:audit server concurrency"`
+	Json     bool     `arg:"help:output json report"`
+	Host     []string `arg:"positional,help:audit servername [default: all servers]"`
+}
+
+// keyscan sub command option
+type KeyscanCommandOption struct {
+	File     string   `arg:"-f,help:config file path"`
+	Parallel int      `arg:"-P,help:keyscan server concurrency"`
+	Hash     bool     `arg:"help:hash hostname in known_hosts"`
+	Print    bool     `arg:"help:print known_hosts lines to stdout (not write known_hosts)"`
+	Host     []string `arg:"positional,help:keyscan servername [default: all servers]"`
+}
+
+// config check sub command option
+type ConfigCheckCommandOption struct {
+	File   string `arg:"-f,help:config file path"`
+	FailOn string `arg:"--fail-on,help:exit status 1 if finding of this severity or higher (warn|error)"`
+	Json   bool   `arg:"help:output json report"`
+}
+
+// check-config sub command option
+type CheckConfigCommandOption struct {
+	File string `arg:"-f,help:config file path"`
+	Json bool   `arg:"help:output json"`
+}
+
+// config sync sub command option
+type ConfigSyncCommandOption struct {
+	File string   `arg:"-f,help:config file path"`
+	Name []string `arg:"positional,help:sync name [default: all]"`
+}
+
+// probe sub command option
+type ProbeCommandOption struct {
+	File      string `arg:"-f,help:config file path"`
+	Tcp       string `arg:"--tcp,required,help:target [host:]port from remote server (default host is localhost)"`
+	UntilOpen bool   `arg:"--until-open,help:wait until port is open"`
+	Timeout   int    `arg:"-t,help:wait timeout(sec). 0 is wait forever"`
+	Host      string `arg:"positional,required,help:probe servername"`
+}
+
+// doctor sub command option
+type DoctorCommandOption struct {
+	File string `arg:"-f,help:config file path"`
+	Host string `arg:"positional,required,help:troubleshoot servername"`
+}
+
+// log show sub command option
+type LogShowCommandOption struct {
+	File     string `arg:"-f,help:config file path"`
+	Host     string `arg:"-H,help:latest log of servername"`
+	Commands bool   `arg:"--commands,help:list commands (need shell_integration)"`
+	Output   int    `arg:"--output,help:print output of N th command"`
+	Json     bool   `arg:"help:output json (with --commands)"`
+	Log      string `arg:"positional,help:session log file [default: latest log]"`
+}
+
+// keychain sub command option
+type KeychainCommandOption struct {
+	File string `arg:"-f,help:config file path"`
+	Key  string `arg:"--key,help:key file path (need if server has multiple keys)"`
+	Host string `arg:"positional,required,help:servername"`
+}
+
+// self-update sub command option
+type SelfUpdateCommandOption struct {
+	Channel string `arg:"help:release channel (stable|beta)"`
+}
+
+// Exec sub command, if os.Args[1] is sub command name
+func execSubCommand(defaultConfPath string) {
+	if len(os.Args) < 2 {
+		return
+	}
+
+	switch os.Args[1] {
+	case "wol":
+		os.Exit(wolCommand(defaultConfPath, os.Args[2:]))
+	case "wait":
+		os.Exit(waitCommand(defaultConfPath, os.Args[2:]))
+	case "reboot":
+		os.Exit(rebootCommand(defaultConfPath, os.Args[2:]))
+	case "key":
+		os.Exit(keyCommand(os.Args[2:]))
+	case "keygen":
+		os.Exit(keygenCommand(defaultConfPath, os.Args[2:]))
+	case "self-update":
+		os.Exit(selfUpdateCommand(os.Args[2:]))
+	case "stats":
+		os.Exit(statsCommand(os.Args[2:]))
+	case "bench":
+		os.Exit(benchCommand(defaultConfPath, os.Args[2:]))
+	case "check":
+		os.Exit(checkCommand(defaultConfPath, os.Args[2:]))
+	case "audit":
+		os.Exit(auditCommand(defaultConfPath, os.Args[2:]))
+	case "keyscan":
+		os.Exit(keyscanCommand(defaultConfPath, os.Args[2:]))
+	case "config":
+		os.Exit(configCommand(defaultConfPath, os.Args[2:]))
+	case "check-config":
+		os.Exit(checkConfigCommand(defaultConfPath, os.Args[2:]))
+	case "probe":
+		os.Exit(probeCommand(defaultConfPath, os.Args[2:]))
+	case "doctor":
+		os.Exit(doctorCommand(defaultConfPath, os.Args[2:]))
+	case "log":
+		os.Exit(logCommand(defaultConfPath, os.Args[2:]))
+	case "keychain":
+		os.Exit(keychainCommand(defaultConfPath, os.Args[2:]))
+	}
+}
+
+// lssh wol <host>
+func wolCommand(defaultConfPath string, subArgs []string) int {
+	var args WolCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh wol", &args, subArgs)
+
+	listConf := readSubCommandConfig(args.File, args.Host)
+
+	if err := ssh.WakeOnLan(args.Host, listConf); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Send magic packet :%s\n", args.Host)
+	return 0
+}
+
+// lssh bench <host>
+func benchCommand(defaultConfPath string, subArgs []string) int {
+	var args BenchCommandOption
+	args.File = defaultConfPath
+	args.Size = 16
+	args.Count = 10
+	parseSubCommand("lssh bench", &args, subArgs)
+
+	listConf := readSubCommandConfig(args.File, args.Host)
+
+	return ssh.Bench(args.Host, listConf, args.Size*1024*1024, args.Count)
+}
+
+// lssh check [host...]
+func checkCommand(defaultConfPath string, subArgs []string) int {
+	var args CheckCommandOption
+	args.File = defaultConfPath
+	args.Parallel = 8
+	parseSubCommand("lssh check", &args, subArgs)
+
+	listConf := conf.ConfigCheckRead(args.File)
+	nameList := conf.GetNameList(listConf)
+	if len(args.Host) == 0 {
+		args.Host = nameList
+		sort.Strings(args.Host)
+	}
+	for _, host := range args.Host {
+		if check.CheckInputServerExit(host, nameList) == false {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", host, i18n.T(i18n.ServerNotFound))
+			return 1
+		}
+	}
+
+	option := ssh.HealthOption{
+		ProbeCmd: args.ProbeCmd,
+		Parallel: args.Parallel,
+		Json:     args.Json,
+	}
+	return ssh.HealthCheck(args.Host, listConf, option)
+}
+
+// lssh audit [host...]
+func auditCommand(defaultConfPath string, subArgs []string) int {
+	var args AuditCommandOption
+	args.File = defaultConfPath
+	args.Parallel = 8
+	parseSubCommand("lssh audit", &args, subArgs)
+
+	listConf := conf.ConfigCheckRead(args.File)
+	nameList := conf.GetNameList(listConf)
+	if len(args.Host) == 0 {
+		args.Host = nameList
+		sort.Strings(args.Host)
+	}
+	for _, host := range args.Host {
+		if check.CheckInputServerExit(host, nameList) == false {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", host, i18n.T(i18n.ServerNotFound))
+			return 1
+		}
+	}
+
+	option := ssh.AuditOption{
+		Parallel: args.Parallel,
+		Json:     args.Json,
+	}
+	return ssh.Audit(args.Host, listConf, option)
+}
+
+// lssh keyscan [--hash] [host...]
+func keyscanCommand(defaultConfPath string, subArgs []string) int {
+	var args KeyscanCommandOption
+	args.File = defaultConfPath
+	args.Parallel = 8
+	parseSubCommand("lssh keyscan", &args, subArgs)
+
+	listConf := conf.ConfigCheckRead(args.File)
+	nameList := conf.GetNameList(listConf)
+	if len(args.Host) == 0 {
+		args.Host = nameList
+		sort.Strings(args.Host)
+	}
+	for _, host := range args.Host {
+		if check.CheckInputServerExit(host, nameList) == false {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", host, i18n.T(i18n.ServerNotFound))
+			return 1
+		}
+	}
+
+	option := ssh.KeyscanOption{
+		Parallel: args.Parallel,
+		Hash:     args.Hash,
+		Print:    args.Print,
+	}
+	return ssh.Keyscan(args.Host, listConf, option)
+}
+
+// lssh config check [--fail-on warn|error]
+// lssh config sync [name...]
+func configCommand(defaultConfPath string, subArgs []string) int {
+	if len(subArgs) > 0 && subArgs[0] == "sync" {
+		return configSyncCommand(defaultConfPath, subArgs[1:])
+	}
+	if len(subArgs) == 0 || subArgs[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: lssh config check [--fail-on warn|error] [--json]")
+		fmt.Fprintln(os.Stderr, "       lssh config sync [name...]")
+		return 1
+	}
+
+	var args ConfigCheckCommandOption
+	args.File = defaultConfPath
+	args.FailOn = conf.SeverityError
+	parseSubCommand("lssh config check", &args, subArgs[1:])
+	if err := conf.CheckSeverity(args.FailOn); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	listConf := conf.ConfigCheckRead(args.File)
+	findings := conf.Lint(args.File, listConf)
+
+	exitStatus := 0
+	for _, f := range findings {
+		if f.IsOver(args.FailOn) {
+			exitStatus = 1
+		}
+	}
+
+	if args.Json {
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+		return exitStatus
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Severity\tServerName\tMessage\t")
+	for _, f := range findings {
+		server := f.Server
+		if server == "" {
+			server = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", f.Severity, server, f.Message)
+	}
+	w.Flush()
+	return exitStatus
+}
+
+// lssh check-config [--json]
+func checkConfigCommand(defaultConfPath string, subArgs []string) int {
+	var args CheckConfigCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh check-config", &args, subArgs)
+
+	errs := conf.Validate(args.File)
+	exitStatus := 0
+	if len(errs) > 0 {
+		exitStatus = 1
+	}
+
+	if args.Json {
+		if errs == nil {
+			errs = []conf.ConfigError{}
+		}
+		data, _ := json.MarshalIndent(errs, "", "  ")
+		fmt.Println(string(data))
+		return exitStatus
+	}
+
+	for _, e := range errs {
+		fmt.Println(e.Error())
+	}
+	if exitStatus == 0 {
+		fmt.Printf("%s: ok\n", args.File)
+	}
+	return exitStatus
+}
+
+func configSyncCommand(defaultConfPath string, subArgs []string) int {
+	var args ConfigSyncCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh config sync", &args, subArgs)
+
+	listConf := conf.ConfigCheckRead(args.File)
+	if len(args.Name) == 0 {
+		for name := range listConf.Sync {
+			args.Name = append(args.Name, name)
+		}
+		sort.Strings(args.Name)
+	}
+
+	exitStatus := 0
+	for _, name := range args.Name {
+		syncConf, ok := listConf.Sync[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: sync config is not found\n", name)
+			exitStatus = 1
+			continue
+		}
+
+		added, removed, err := conf.Sync(name, syncConf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			exitStatus = 1
+			continue
+		}
+		fmt.Printf("%s: %d added, %d removed\n", name, len(added), len(removed))
+		for _, server := range added {
+			fmt.Printf("  + %s\n", server)
+		}
+		for _, server := range removed {
+			fmt.Printf("  - %s\n", server)
+		}
+	}
+	return exitStatus
+}
+
+// lssh probe <host> --tcp [host:]port [--until-open]
+func probeCommand(defaultConfPath string, subArgs []string) int {
+	var args ProbeCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh probe", &args, subArgs)
+
+	host, port, err := conf.SplitHostPort(args.Tcp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if port == "" {
+		// port only ("5432")
+		host, port = "localhost", host
+	}
+	if err := conf.CheckPort(port); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	listConf := readSubCommandConfig(args.File, args.Host)
+	target := net.JoinHostPort(host, port)
+	return ssh.Probe(args.Host, listConf, target, args.UntilOpen, time.Duration(args.Timeout)*time.Second)
+}
+
+// lssh doctor <host>
+func doctorCommand(defaultConfPath string, subArgs []string) int {
+	var args DoctorCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh doctor", &args, subArgs)
+
+	listConf := readSubCommandConfig(args.File, args.Host)
+	return ssh.Doctor(args.Host, listConf)
+}
+
+// lssh log show [--commands] [log file]
+func logCommand(defaultConfPath string, subArgs []string) int {
+	if len(subArgs) == 0 || subArgs[0] != "show" {
+		fmt.Fprintln(os.Stderr, "usage: lssh log show [--commands] [--output N] [log file]")
+		return 1
+	}
+
+	var args LogShowCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh log show", &args, subArgs[1:])
+
+	logPath := args.Log
+	if logPath == "" {
+		listConf := conf.ConfigCheckRead(args.File)
+		var err error
+		if logPath, err = sessionlog.GetLatestFile(listConf.Log.Dir, args.Host); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !args.Commands && args.Output == 0 {
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	commands, err := sessionlog.ParseCommands(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Print N th command output
+	if args.Output > 0 {
+		if args.Output > len(commands) {
+			fmt.Fprintf(os.Stderr, "command %d is not found (%d commands)\n", args.Output, len(commands))
+			return 1
+		}
+		c := commands[args.Output-1]
+		os.Stdout.Write(data[c.OutputStart:c.OutputEnd])
+		return 0
+	}
+
+	if args.Json {
+		out, _ := json.MarshalIndent(commands, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "No\tStart\tDuration\tExit\tOutput\tCommand\t")
+	for i, c := range commands {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d-%d\t%s\t\n", i+1, c.Start.Format("2006-01-02 15:04:05"),
+			c.Duration(), c.ExitStatus, c.OutputStart, c.OutputEnd, c.Command)
+	}
+	w.Flush()
+	return 0
+}
+
+// lssh keychain add|delete [--key path] <host>
+func keychainCommand(defaultConfPath string, subArgs []string) int {
+	if len(subArgs) == 0 || (subArgs[0] != "add" && subArgs[0] != "delete") {
+		fmt.Fprintln(os.Stderr, "usage: lssh keychain add|delete [--key path] <host>")
+		return 1
+	}
+
+	var args KeychainCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh keychain "+subArgs[0], &args, subArgs[1:])
+	listConf := readSubCommandConfig(args.File, args.Host)
+
+	// key passphrase is stored per server and key path
+	identityList := conf.GetIdentityList(listConf.Server[args.Host], listConf.Identities)
+	identities := ssh.GetIdentities(args.Host, listConf)
+	keyPaths := []string{}
+	for i, identity := range identities {
+		if args.Key == "" || args.Key == identityList[i] || args.Key == identity {
+			keyPaths = append(keyPaths, identity)
+		}
+	}
+	if len(keyPaths) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: key is not found\n", args.Host)
+		return 1
+	}
+
+	if subArgs[0] == "delete" {
+		exitStatus := 0
+		for _, keyPath := range keyPaths {
+			if err := keychain.Delete(keychain.KeyAccount(args.Host, keyPath)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exitStatus = 1
+			}
+		}
+		return exitStatus
+	}
+
+	if len(keyPaths) > 1 {
+		fmt.Fprintf(os.Stderr, "%s: server has multiple keys, specify it with --key\n", args.Host)
+		return 1
+	}
+
+	passphrase, err := prompt.Ask(listConf.Prompt.Passphrase, fmt.Sprintf("Enter passphrase for key '%s': ", keyPaths[0]), false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := keychain.Set(keychain.KeyAccount(args.Host, keyPaths[0]), passphrase); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !listConf.Server[args.Host].Keychain {
+		fmt.Fprintf(os.Stderr, "Stored. Set `keychain = true` in %s config to use it.\n", args.Host)
+	}
+	return 0
+}
+
+// lssh wait <host> [--then connect|cmd]
+func waitCommand(defaultConfPath string, subArgs []string) int {
+	var args WaitCommandOption
+	args.File = defaultConfPath
+	parseSubCommand("lssh wait", &args, subArgs)
+
+	if args.Then == "cmd" && len(args.Command) == 0 {
+		fmt.Fprintln(os.Stderr, "--then cmd need remote exec command.")
+		return 1
+	}
+	if args.Then != "" && args.Then != "connect" && args.Then != "cmd" {
+		fmt.Fprintf(os.Stderr, "--then %s is not supported.\n", args.Then)
+		return 1
+	}
+
+	listConf := readSubCommandConfig(args.File, args.Host)
+	if err := ssh.WaitSsh(args.Host, listConf, time.Duration(args.Timeout)*time.Second); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Server is reachable :%s\n", args.Host)
+
+	switch args.Then {
+	case "connect":
+		return ssh.ConnectSshTerminal(args.Host, listConf)
+	case "cmd":
+		return ssh.ConnectSshCommand(args.Host, listConf, args.Command...)
+	}
+	return 0
+}
+
+// lssh reboot <host>...
+func rebootCommand(defaultConfPath string, subArgs []string) int {
+	var args RebootCommandOption
+	args.File = defaultConfPath
+	args.RebootCmd = "sudo reboot"
+	args.Parallel = 1
+	args.Timeout = 600
+	parseSubCommand("lssh reboot", &args, subArgs)
+
+	listConf := conf.ConfigCheckRead(args.File)
+	nameList := conf.GetNameList(listConf)
+	for _, host := range args.Host {
+		if check.CheckInputServerExit(host, nameList) == false {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", host, i18n.T(i18n.ServerNotFound))
+			return 1
+		}
+	}
+
+	option := ssh.RebootOption{
+		RebootCmd: args.RebootCmd,
+		HealthCmd: args.HealthCmd,
+		Parallel:  args.Parallel,
+		Timeout:   time.Duration(args.Timeout) * time.Second,
+	}
+	return ssh.Reboot(args.Host, listConf, option)
+}
+
+// lssh key download-resident
+func keyCommand(subArgs []string) int {
+	if len(subArgs) == 0 || subArgs[0] != "download-resident" {
+		fmt.Fprintln(os.Stderr, "usage: lssh key download-resident")
+		return 1
+	}
+
+	keys, err := key.DownloadResident()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	sort.Strings(keys)
+
+	// Print config example
+	fmt.Fprintln(os.Stderr, "Downloaded resident keys. Add to config:")
+	quoteKeys := []string{}
+	for _, k := range keys {
+		quoteKeys = append(quoteKeys, strconv.Quote(k))
+	}
+	fmt.Printf("identities = [%s]\n", strings.Join(quoteKeys, ", "))
+	return 0
+}
+
+// lssh keygen --type ed25519 --deploy <host>...
+func keygenCommand(defaultConfPath string, subArgs []string) int {
+	var args KeygenCommandOption
+	args.File = defaultConfPath
+	args.Type = "ed25519"
+	parseSubCommand("lssh keygen", &args, subArgs)
+
+	var listConf conf.Config
+	if len(args.Deploy) > 0 {
+		listConf = conf.ConfigCheckRead(args.File)
+		nameList := conf.GetNameList(listConf)
+		for _, host := range args.Deploy {
+			if check.CheckInputServerExit(host, nameList) == false {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", host, i18n.T(i18n.ServerNotFound))
+				return 1
+			}
+		}
+	}
+
+	// Generate key pair
+	keyPath := args.Output
+	if keyPath == "" {
+		var err error
+		keyPath, err = key.GetDefaultKeyPath(args.Type)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	usr, _ := user.Current()
+	hostname, _ := os.Hostname()
+	if err := key.Generate(args.Type, keyPath, usr.Username+"@"+hostname+" (lssh)"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	pubKey, err := ioutil.ReadFile(keyPath + ".pub")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// authorized_keys expiry-time option (OpenSSH 8.2 or later)
+	authorizedKey := string(pubKey)
+	expireDate := ""
+	if args.Expire > 0 {
+		expire := time.Now().AddDate(0, 0, args.Expire)
+		expireDate = expire.Format("2006-01-02")
+		authorizedKey = "expiry-time=\"" + expire.Format("20060102") + "\" " + authorizedKey
+	}
+
+	// Deploy public key
+	exitStatus := 0
+	for _, host := range args.Deploy {
+		if err := ssh.DeployPublicKey(host, listConf, authorizedKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Deploy error  :%s (%v)\n", host, err)
+			exitStatus = 1
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Deployed      :%s\n", host)
+	}
+
+	// Print config example
+	fmt.Fprintln(os.Stderr, "Add to server config:")
+	fmt.Printf("key = %s\n", strconv.Quote(keyPath))
+	if expireDate != "" {
+		fmt.Printf("key_expire = %s\n", strconv.Quote(expireDate))
+	}
+	return exitStatus
+}
+
+// lssh self-update [--channel stable|beta]
+func selfUpdateCommand(subArgs []string) int {
+	var args SelfUpdateCommandOption
+	args.Channel = "stable"
+	parseSubCommand("lssh self-update", &args, subArgs)
+
+	if err := update.SelfUpdate(version, args.Channel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// lssh stats
+func statsCommand(subArgs []string) int {
+	var args struct{}
+	parseSubCommand("lssh stats", &args, subArgs)
+
+	entries, err := history.Read()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	history.PrintStats(os.Stdout, entries)
+	return 0
+}
+
+// Read config and check input server exist
+func readSubCommandConfig(confPath string, host string) (listConf conf.Config) {
+	listConf = conf.ConfigCheckRead(confPath)
+	nameList := conf.GetNameList(listConf)
+	if check.CheckInputServerExit(host, nameList) == false {
+		fmt.Fprintln(os.Stderr, i18n.T(i18n.ServerNotFound))
+		os.Exit(1)
+	}
+	return
+}
+
+// Parse sub command args
+func parseSubCommand(program string, dest interface{}, subArgs []string) {
+	p, err := arg.NewParser(arg.Config{Program: program}, dest)
+	if err != nil {
+		panic(err)
+	}
+	if err := p.Parse(subArgs); err != nil {
+		if err == arg.ErrHelp {
+			p.WriteHelp(os.Stdout)
+			os.Exit(0)
+		}
+		p.Fail(err.Error())
+	}
+}
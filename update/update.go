@@ -0,0 +1,233 @@
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const releasesURL = "https://api.github.com/repos/blacknon/lssh/releases"
+
+type release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	Assets     []asset `json:"assets"`
+}
+
+type asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// Update running binary to latest release of channel (stable|beta).
+// Verify sha256 checksum, and replace binary atomically.
+// Release signature is not verified (lssh releases have no published signing key to pin).
+func SelfUpdate(currentVersion string, channel string) error {
+	rel, err := getLatestRelease(channel)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimPrefix(rel.TagName, "v") == strings.TrimPrefix(currentVersion, "v") {
+		fmt.Fprintf(os.Stderr, "Already up to date :%s\n", rel.TagName)
+		return nil
+	}
+
+	binAsset, sumAsset, err := findAssets(rel)
+	if err != nil {
+		return err
+	}
+
+	// Download
+	fmt.Fprintf(os.Stderr, "Download      :%s\n", binAsset.Name)
+	data, err := download(binAsset.URL)
+	if err != nil {
+		return err
+	}
+
+	// Verify checksum
+	sums, err := download(sumAsset.URL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(binAsset.Name, data, sums); err != nil {
+		return err
+	}
+
+	// Extract binary
+	bin := data
+	if name := strings.ToLower(binAsset.Name); strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		if bin, err = extractBinary(data, "lssh"); err != nil {
+			return err
+		}
+	}
+
+	if err := replaceExecutable(bin); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Updated       :%s => %s\n", currentVersion, rel.TagName)
+	return nil
+}
+
+// Get latest release (stable: not prerelease, beta: include prerelease)
+func getLatestRelease(channel string) (rel release, err error) {
+	if channel != "stable" && channel != "beta" {
+		err = fmt.Errorf("channel %s is not supported (stable|beta)", channel)
+		return
+	}
+
+	data, err := download(releasesURL)
+	if err != nil {
+		return
+	}
+	releases := []release{}
+	if err = json.Unmarshal(data, &releases); err != nil {
+		return
+	}
+
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel == "stable") {
+			continue
+		}
+		return r, nil
+	}
+	err = fmt.Errorf("release not found at channel %s", channel)
+	return
+}
+
+// Find binary asset for this platform and checksum asset.
+// Binary asset is "*_<goos>_<goarch>.tar.gz" (or ".tgz", or bare binary), and it must be only one.
+func findAssets(rel release) (binAsset asset, sumAsset asset, err error) {
+	binRegexp := regexp.MustCompile(`_` + runtime.GOOS + `_` + runtime.GOARCH + `(\.tar\.gz|\.tgz)?$`)
+
+	binAssets := []asset{}
+	sumAssets := []asset{}
+	for _, a := range rel.Assets {
+		name := strings.ToLower(a.Name)
+		switch {
+		case strings.HasSuffix(name, ".sig") || strings.HasSuffix(name, ".asc") || strings.HasSuffix(name, ".pem"):
+		case strings.Contains(name, "checksums") || strings.Contains(name, "sha256sums"):
+			sumAssets = append(sumAssets, a)
+		case binRegexp.MatchString(name):
+			binAssets = append(binAssets, a)
+		}
+	}
+
+	switch {
+	case len(binAssets) == 0:
+		err = fmt.Errorf("%s: binary for %s/%s not found", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	case len(binAssets) > 1:
+		err = fmt.Errorf("%s: multiple binaries for %s/%s found", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	case len(sumAssets) == 0:
+		err = fmt.Errorf("%s: checksum file not found", rel.TagName)
+	case len(sumAssets) > 1:
+		err = fmt.Errorf("%s: multiple checksum files found", rel.TagName)
+	default:
+		binAsset, sumAsset = binAssets[0], sumAssets[0]
+	}
+	return
+}
+
+func download(url string) (data []byte, err error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", url, resp.Status)
+		return
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Verify sha256 checksum ("<hash>  <file name>" format)
+func verifyChecksum(name string, data []byte, sums []byte) error {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if fields[0] != hash {
+			return fmt.Errorf("%s: checksum mismatch", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: checksum not found", name)
+}
+
+// Extract binary from tar.gz archive
+func extractBinary(data []byte, binName string) (bin []byte, err error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, nextErr := tr.Next()
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binName {
+			return ioutil.ReadAll(tr)
+		}
+	}
+	err = fmt.Errorf("%s not found in archive", binName)
+	return
+}
+
+// Replace running executable (write temporary file at same directory, and rename)
+func replaceExecutable(bin []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), ".lssh-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), exe)
+}
@@ -0,0 +1,49 @@
+package wol
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const defaultBroadcast = "255.255.255.255:9"
+
+// Create magic packet (0xff * 6 + MAC address * 16)
+func MagicPacket(mac string) (packet []byte, err error) {
+	hwAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return
+	}
+	if len(hwAddr) != 6 {
+		err = fmt.Errorf("%s: not EUI-48 MAC address", mac)
+		return
+	}
+
+	packet = append(packet, bytes.Repeat([]byte{0xff}, 6)...)
+	packet = append(packet, bytes.Repeat(hwAddr, 16)...)
+	return
+}
+
+// Send magic packet to broadcast address
+func Send(mac string, broadcast string) (err error) {
+	packet, err := MagicPacket(mac)
+	if err != nil {
+		return
+	}
+
+	if broadcast == "" {
+		broadcast = defaultBroadcast
+	} else if _, _, splitErr := net.SplitHostPort(broadcast); splitErr != nil {
+		broadcast = net.JoinHostPort(strings.Trim(broadcast, "[]"), "9")
+	}
+
+	conn, err := net.Dial("udp", broadcast)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(packet)
+	return
+}